@@ -10,7 +10,9 @@ import (
 	"order_service/internal/service"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -24,6 +26,12 @@ var address = []string{"localhost:9091", "localhost:9092", "localhost:9093"}
 
 func main() {
 	dbType := repository.DatabaseType(os.Getenv("DB_TYPE"))
+
+	// order_service migrate up|down|status — управление схемой без запуска сервиса
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(dbType, os.Args[2:]))
+	}
+
 	repo, err := repository.NewDatabaseConnection(dbType)
 	if err != nil {
 		log.Fatal("Error creating repository: ", err)
@@ -40,7 +48,9 @@ func main() {
 	}
 
 	// Создаём service
-	orderService := service.NewOrderService(repo, productClient, paymentClient)
+	orderService := service.NewOrderService(repo, productClient, paymentClient,
+		service.WithIdempotencyTTL(envDuration("IDEMPOTENCY_KEY_TTL", service.DefaultIdempotencyTTL)),
+	)
 
 	h := kafka.NewHandler(orderService, productClient)
 	c1, err := service.NewConsumer(h, address, topic, consumerGroup, 1)
@@ -62,8 +72,45 @@ func main() {
 	go c2.Start()
 	go c3.Start()
 
+	// Фоновое снятие просроченных резервов
+	expiryWorker := service.NewReservationExpiryWorker(repo, paymentClient, service.ExpiryConfig{
+		Interval:               envDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+		TTL:                    envDuration("RESERVATION_TTL", 5*time.Minute),
+		InvalidatePaymentLinks: os.Getenv("INVALIDATE_PAYMENT_LINKS") == "true",
+	})
+	go expiryWorker.Start()
+
+	// Восстановление саг создания заказа, прерванных падением процесса
+	sagaWorker := service.NewSagaRecoveryWorker(orderService,
+		envDuration("SAGA_RECOVERY_INTERVAL", time.Minute),
+		envDuration("SAGA_RECOVERY_AFTER", 2*time.Minute),
+	)
+	go sagaWorker.Start()
+
+	// Перенос старых заказов в архивные таблицы
+	archiveWorker := service.NewOrderArchiveWorker(repo, service.ArchiveConfig{
+		Interval:  envDuration("ORDER_ARCHIVE_INTERVAL", time.Hour),
+		Age:       envDuration("ORDER_ARCHIVE_AFTER", 365*24*time.Hour),
+		BatchSize: 500,
+	})
+	go archiveWorker.Start()
+
+	// Публикация доменных событий заказов из outbox
+	producer, err := service.NewProducer(address, envString("ORDER_EVENTS_TOPIC", "order_events"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	outboxRelay := service.NewOutboxRelay(repo, producer, service.OutboxConfig{
+		Interval:  envDuration("OUTBOX_RELAY_INTERVAL", time.Second),
+		BatchSize: 100,
+	})
+	go outboxRelay.Start()
+
 	// Создаём REST handler
-	orderHandler := rest.NewOrderHandler(orderService)
+	orderHandler := rest.NewOrderHandler(orderService, rest.WithOrderLimits(rest.OrderLimits{
+		MaxItems:    int(envInt("ORDER_MAX_ITEMS", int64(rest.DefaultOrderLimits.MaxItems))),
+		MaxQuantity: envInt("ORDER_MAX_ITEM_QUANTITY", rest.DefaultOrderLimits.MaxQuantity),
+	}))
 
 	// Создаём роутер
 	router := rest.NewRouter(orderHandler)
@@ -90,7 +137,48 @@ func main() {
 	if err := c3.Stop(); err != nil {
 		logrus.Error("Ошибка при остановке c3:", err)
 	}
+	expiryWorker.Stop()
+	sagaWorker.Stop()
+	archiveWorker.Stop()
+	outboxRelay.Stop()
+	producer.Close()
 
 	logrus.Info("Консюмеры успешно остановлены, завершаем работу.")
 	os.Exit(0) // Завершаем программу после остановки всех консюмеров
 }
+
+// envDuration читает длительность из переменной окружения (например "30s", "5m")
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logrus.Warnf("Некорректное значение %s=%q, используется %s", name, value, def)
+		return def
+	}
+	return d
+}
+
+// envInt читает положительное целое из переменной окружения
+func envInt(name string, def int64) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		logrus.Warnf("Некорректное значение %s=%q, используется %d", name, value, def)
+		return def
+	}
+	return n
+}
+
+// envString читает строку из переменной окружения или возвращает значение по умолчанию
+func envString(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
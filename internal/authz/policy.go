@@ -0,0 +1,135 @@
+// Package authz решает, может ли пользователь выполнить операцию с заказом.
+// Сервис заказов спрашивает политику перед каждой операцией.
+package authz
+
+import (
+	"context"
+	"fmt"
+	"order_service/internal/domainerr"
+	"order_service/internal/entity"
+)
+
+// ErrForbidden — операция запрещена; errors.Is(err, ErrForbidden) верно для любого отказа
+var ErrForbidden = domainerr.New(domainerr.Forbidden, "access denied")
+
+// Action — операция, на которую проверяются права
+type Action string
+
+const (
+	ActionCreate       Action = "create"        // создать заказ от имени пользователя
+	ActionView         Action = "view"          // прочитать заказ и его историю
+	ActionViewDeleted  Action = "view_deleted"  // прочитать мягко удалённый заказ
+	ActionList         Action = "list"          // получить список заказов пользователя
+	ActionListAll      Action = "list_all"      // получить список заказов всех пользователей
+	ActionCancel       Action = "cancel"        // отменить заказ
+	ActionUpdateStatus Action = "update_status" // вручную сменить статус
+	ActionDelete       Action = "delete"        // мягко удалить заказ
+	ActionRestore      Action = "restore"       // восстановить удалённый заказ
+	ActionRecoverSagas Action = "recover_sagas" // завершить или откатить прерванные саги
+)
+
+// Principal — пользователь, от имени которого выполняется запрос
+type Principal struct {
+	UserID int64
+	Role   string
+	// System — внутренний вызов сервиса: фоновый процесс, обработчик Kafka или сага.
+	// По JWT такой принципал не выдаётся.
+	System bool
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст запроса от имени p
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// WithSystem возвращает контекст внутреннего вызова сервиса. Его получают фоновые
+// процессы и обработчики Kafka: без принципала в контексте политика отказывает.
+func WithSystem(ctx context.Context) context.Context {
+	return WithPrincipal(ctx, Principal{System: true})
+}
+
+// PrincipalFromContext возвращает пользователя запроса
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// DeniedError описывает отказ в доступе. Если у пользователя нет права даже видеть
+// заказ, отказ скрыт: чтобы не раскрывать чужие заказы, он выглядит как отсутствие
+// заказа вида domainerr.NotFound. Текст ошибки уходит клиенту, поэтому владельца
+// заказа он не называет.
+type DeniedError struct {
+	Principal Principal
+	Action    Action
+	OwnerID   int64 // владелец заказа; 0, если операция не относится к заказу пользователя
+	Hidden    bool
+}
+
+func (e *DeniedError) Error() string {
+	if e.Hidden {
+		return "order not found"
+	}
+	return fmt.Sprintf("%s: user %d with role %q may not %s orders",
+		ErrForbidden, e.Principal.UserID, e.Principal.Role, e.Action)
+}
+
+func (e *DeniedError) Unwrap() []error {
+	if e.Hidden {
+		return []error{domainerr.NotFound, ErrForbidden}
+	}
+	return []error{ErrForbidden}
+}
+
+// orderActions — операции с конкретным существующим заказом
+var orderActions = map[Action]bool{
+	ActionView:         true,
+	ActionViewDeleted:  true,
+	ActionCancel:       true,
+	ActionUpdateStatus: true,
+	ActionDelete:       true,
+	ActionRestore:      true,
+}
+
+// ownerActions — операции, которые пользователь выполняет со своими заказами
+var ownerActions = map[Action]bool{
+	ActionCreate: true,
+	ActionView:   true,
+	ActionList:   true,
+	ActionCancel: true,
+}
+
+// supportActions — операции поддержки с заказами любых пользователей
+var supportActions = map[Action]bool{
+	ActionView:        true,
+	ActionViewDeleted: true,
+	ActionList:        true,
+	ActionListAll:     true,
+}
+
+// Authorize проверяет, может ли пользователь из ctx выполнить action с заказами
+// пользователя ownerID. Внутренние вызовы и администратор могут всё, поддержка —
+// только читать, остальные — работать со своими заказами. Контекст без принципала
+// получает отказ: так маршрут, подключённый в обход JWTMiddleware, не получит
+// лишних прав. Отказ возвращается как *DeniedError.
+func Authorize(ctx context.Context, action Action, ownerID int64) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return &DeniedError{Action: action, OwnerID: ownerID}
+	}
+
+	switch {
+	case p.System, p.Role == entity.RoleAdmin:
+		return nil
+	case p.Role == entity.RoleSupport && supportActions[action]:
+		return nil
+	case ownerID != 0 && p.UserID == ownerID && ownerActions[action]:
+		return nil
+	}
+
+	// Чужой заказ, который пользователь не может даже прочитать, выдаём за отсутствующий
+	canView := p.Role == entity.RoleSupport || (ownerID != 0 && p.UserID == ownerID)
+	hidden := orderActions[action] && ownerID != 0 && !canView
+	return &DeniedError{Principal: p, Action: action, OwnerID: ownerID, Hidden: hidden}
+}
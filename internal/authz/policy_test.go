@@ -0,0 +1,90 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"order_service/internal/domainerr"
+	"order_service/internal/entity"
+)
+
+func TestAuthorize(t *testing.T) {
+	owner := Principal{UserID: 7}
+	stranger := Principal{UserID: 8}
+	support := Principal{UserID: 100, Role: entity.RoleSupport}
+	admin := Principal{UserID: 101, Role: entity.RoleAdmin}
+	system := Principal{System: true}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		action    Action
+		ownerID   int64
+		allowed   bool
+		hidden    bool
+	}{
+		{"OwnerViews", &owner, ActionView, 7, true, false},
+		{"OwnerCancels", &owner, ActionCancel, 7, true, false},
+		{"OwnerCannotUpdateStatus", &owner, ActionUpdateStatus, 7, false, false},
+		{"StrangerViewHidden", &stranger, ActionView, 7, false, true},
+		{"StrangerCancelHidden", &stranger, ActionCancel, 7, false, true},
+		{"StrangerCannotList", &stranger, ActionList, 7, false, false},
+		{"UserCannotListAll", &owner, ActionListAll, 0, false, false},
+		{"UserCannotDelete", &owner, ActionDelete, 0, false, false},
+		{"SupportViews", &support, ActionView, 7, true, false},
+		{"SupportListsAll", &support, ActionListAll, 0, true, false},
+		{"SupportViewsDeleted", &support, ActionViewDeleted, 0, true, false},
+		{"UserCannotViewDeleted", &owner, ActionViewDeleted, 0, false, false},
+		{"SupportCannotUpdateStatus", &support, ActionUpdateStatus, 7, false, false},
+		{"SupportCannotDelete", &support, ActionDelete, 0, false, false},
+		{"AdminUpdatesStatus", &admin, ActionUpdateStatus, 7, true, false},
+		{"AdminRestores", &admin, ActionRestore, 0, true, false},
+		{"UserCannotRecoverSagas", &owner, ActionRecoverSagas, 0, false, false},
+		{"SystemUpdatesStatus", &system, ActionUpdateStatus, 7, true, false},
+		{"SystemRecoversSagas", &system, ActionRecoverSagas, 0, true, false},
+		{"NoPrincipalDenied", nil, ActionUpdateStatus, 7, false, false},
+		{"NoPrincipalCannotView", nil, ActionView, 7, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = WithPrincipal(ctx, *tt.principal)
+			}
+
+			// Выполнение
+			err := Authorize(ctx, tt.action, tt.ownerID)
+
+			// Проверка
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("expected access, got %v", err)
+				}
+				return
+			}
+			var denied *DeniedError
+			if !errors.As(err, &denied) || !errors.Is(err, ErrForbidden) {
+				t.Fatalf("expected *DeniedError, got %v", err)
+			}
+			if errors.Is(err, domainerr.NotFound) != tt.hidden {
+				t.Errorf("expected hidden=%v, got %v", tt.hidden, err)
+			}
+			// Текст отказа уходит клиенту и не называет владельца чужого заказа
+			owner := fmt.Sprintf("user %d", tt.ownerID)
+			if tt.ownerID != 0 && (tt.principal == nil || tt.principal.UserID != tt.ownerID) && strings.Contains(err.Error(), owner) {
+				t.Errorf("expected owner ID to stay out of %q", err)
+			}
+			kind := domainerr.Forbidden
+			if tt.hidden {
+				kind = domainerr.NotFound
+			}
+			if got, _ := domainerr.KindOf(err); got != kind {
+				t.Errorf("expected kind %s, got %s", kind, got)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"order_service/internal/entity"
 	"order_service/internal/paymentpb"
 
 	"google.golang.org/grpc"
@@ -24,14 +25,16 @@ func NewPaymentServiceClient(address string) (*PaymentServiceClient, error) {
 	return &PaymentServiceClient{client: client}, nil
 }
 
-func (p *PaymentServiceClient) GeneratePaymentLink(userID int64, orderID int64, totalPrice float64) (*paymentpb.PaymentResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (p *PaymentServiceClient) GeneratePaymentLink(ctx context.Context, userID int64, orderID int64, totalPrice entity.Money) (*paymentpb.PaymentResponse, error) {
+	ctx, cancel := withCallTimeout(ctx)
 	defer cancel()
 
 	req := &paymentpb.PaymentRequest{
-		UserId:     userID,
-		OrderId:    orderID,
-		TotalPrice: totalPrice,
+		UserId:          userID,
+		OrderId:         orderID,
+		TotalPrice:      totalPrice.Float64(),
+		TotalPriceMinor: totalPrice.Amount,
+		Currency:        totalPrice.Currency,
 	}
 
 	res, err := p.client.GeneratePaymentLink(ctx, req)
@@ -40,3 +43,18 @@ func (p *PaymentServiceClient) GeneratePaymentLink(userID int64, orderID int64,
 	}
 	return res, nil
 }
+
+// InvalidatePaymentLink аннулирует ссылку на оплату заказа
+func (p *PaymentServiceClient) InvalidatePaymentLink(ctx context.Context, orderID int64) error {
+	ctx, cancel := withCallTimeout(ctx)
+	defer cancel()
+
+	res, err := p.client.InvalidatePaymentLink(ctx, &paymentpb.InvalidatePaymentLinkRequest{OrderId: orderID})
+	if err != nil {
+		return fmt.Errorf("failed to invalidate payment link: %w", err)
+	}
+	if res.Error != "" {
+		return fmt.Errorf("failed to invalidate payment link: %s", res.Error)
+	}
+	return nil
+}
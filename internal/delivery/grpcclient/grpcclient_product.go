@@ -26,8 +26,8 @@ func NewProductServiceClient(address string) (*ProductServiceClient, error) {
 	return &ProductServiceClient{client: client}, nil
 }
 
-func (p *ProductServiceClient) GetProductStock(productID []int64) (map[int64]*productpb.ProductStockInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (p *ProductServiceClient) GetProductStock(ctx context.Context, productID []int64) (map[int64]*productpb.ProductStockInfo, error) {
+	ctx, cancel := withCallTimeout(ctx)
 	defer cancel()
 
 	req := &productpb.ProductStockRequest{
@@ -43,8 +43,8 @@ func (p *ProductServiceClient) GetProductStock(productID []int64) (map[int64]*pr
 	return res.StockMap, nil
 }
 
-func (p *ProductServiceClient) UpdateProductStock(order *entity.Order) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (p *ProductServiceClient) UpdateProductStock(ctx context.Context, order *entity.Order) error {
+	ctx, cancel := withCallTimeout(ctx)
 	defer cancel()
 
 	arrReq := []*productpb.UpdateProductStockRequest_StockUpdate{}
@@ -1,14 +1,17 @@
 package grpcclient
 
 import (
+	"context"
+	"order_service/internal/entity"
 	"order_service/internal/paymentpb"
 	"order_service/internal/productpb"
 )
 
 type ProductServiceClientInterface interface {
-	GetProductStock(productIDs []int64) (map[int64]*productpb.ProductStockInfo, error)
+	GetProductStock(ctx context.Context, productIDs []int64) (map[int64]*productpb.ProductStockInfo, error)
 }
 
 type PaymentServiceClientInterface interface {
-	GeneratePaymentLink(userID, orderID int64, amount float64) (*paymentpb.PaymentResponse, error)
+	GeneratePaymentLink(ctx context.Context, userID, orderID int64, amount entity.Money) (*paymentpb.PaymentResponse, error)
+	InvalidatePaymentLink(ctx context.Context, orderID int64) error
 }
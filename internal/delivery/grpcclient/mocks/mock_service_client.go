@@ -10,6 +10,8 @@
 package mocks
 
 import (
+	context "context"
+	entity "order_service/internal/entity"
 	paymentpb "order_service/internal/paymentpb"
 	productpb "order_service/internal/productpb"
 	reflect "reflect"
@@ -42,18 +44,18 @@ func (m *MockProductServiceClientInterface) EXPECT() *MockProductServiceClientIn
 }
 
 // GetProductStock mocks base method.
-func (m *MockProductServiceClientInterface) GetProductStock(productIDs []int64) (map[int64]*productpb.ProductStockInfo, error) {
+func (m *MockProductServiceClientInterface) GetProductStock(ctx context.Context, productIDs []int64) (map[int64]*productpb.ProductStockInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProductStock", productIDs)
+	ret := m.ctrl.Call(m, "GetProductStock", ctx, productIDs)
 	ret0, _ := ret[0].(map[int64]*productpb.ProductStockInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetProductStock indicates an expected call of GetProductStock.
-func (mr *MockProductServiceClientInterfaceMockRecorder) GetProductStock(productIDs any) *gomock.Call {
+func (mr *MockProductServiceClientInterfaceMockRecorder) GetProductStock(ctx, productIDs any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductStock", reflect.TypeOf((*MockProductServiceClientInterface)(nil).GetProductStock), productIDs)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductStock", reflect.TypeOf((*MockProductServiceClientInterface)(nil).GetProductStock), ctx, productIDs)
 }
 
 // MockPaymentServiceClientInterface is a mock of PaymentServiceClientInterface interface.
@@ -81,16 +83,30 @@ func (m *MockPaymentServiceClientInterface) EXPECT() *MockPaymentServiceClientIn
 }
 
 // GeneratePaymentLink mocks base method.
-func (m *MockPaymentServiceClientInterface) GeneratePaymentLink(userID, orderID int64, amount float64) (*paymentpb.PaymentResponse, error) {
+func (m *MockPaymentServiceClientInterface) GeneratePaymentLink(ctx context.Context, userID, orderID int64, amount entity.Money) (*paymentpb.PaymentResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GeneratePaymentLink", userID, orderID, amount)
+	ret := m.ctrl.Call(m, "GeneratePaymentLink", ctx, userID, orderID, amount)
 	ret0, _ := ret[0].(*paymentpb.PaymentResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GeneratePaymentLink indicates an expected call of GeneratePaymentLink.
-func (mr *MockPaymentServiceClientInterfaceMockRecorder) GeneratePaymentLink(userID, orderID, amount any) *gomock.Call {
+func (mr *MockPaymentServiceClientInterfaceMockRecorder) GeneratePaymentLink(ctx, userID, orderID, amount any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GeneratePaymentLink", reflect.TypeOf((*MockPaymentServiceClientInterface)(nil).GeneratePaymentLink), userID, orderID, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GeneratePaymentLink", reflect.TypeOf((*MockPaymentServiceClientInterface)(nil).GeneratePaymentLink), ctx, userID, orderID, amount)
+}
+
+// InvalidatePaymentLink mocks base method.
+func (m *MockPaymentServiceClientInterface) InvalidatePaymentLink(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidatePaymentLink", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidatePaymentLink indicates an expected call of InvalidatePaymentLink.
+func (mr *MockPaymentServiceClientInterfaceMockRecorder) InvalidatePaymentLink(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidatePaymentLink", reflect.TypeOf((*MockPaymentServiceClientInterface)(nil).InvalidatePaymentLink), ctx, orderID)
 }
@@ -0,0 +1,18 @@
+package grpcclient
+
+import (
+	"context"
+	"time"
+)
+
+// callTimeout ограничивает вызов, если вызывающий не задал свой дедлайн
+const callTimeout = 3 * time.Second
+
+// withCallTimeout возвращает контекст вызова. Отмена и дедлайн родительского
+// контекста (например, HTTP-запроса) передаются в вызов как есть.
+func withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, callTimeout)
+}
@@ -1,8 +1,12 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"order_service/internal/authz"
 	"order_service/internal/delivery/grpcclient"
+	"order_service/internal/entity"
 	"order_service/internal/service"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -18,32 +22,59 @@ func NewHandler(orderService service.OrderServiceInterface, productService *grpc
 	return &Handler{orderService: orderService, productService: productService}
 }
 
-func (h *Handler) HandleMessage(message []byte, topic kafka.TopicPartition, cn int64) error {
+func (h *Handler) HandleMessage(ctx context.Context, message []byte, topic kafka.TopicPartition, cn int64) error {
+	// Сообщения приходят от других сервисов, а не от пользователя: заказ меняется от имени сервиса
+	ctx = authz.WithSystem(ctx)
+
 	logrus.Infof("Consumer #%d, Message from kafka with offset %d '%s, on partition %d", cn, topic.Offset, string(message), topic.Partition)
 
 	// Парсим сообщение (допустим, JSON с полем orderID)
 	var event struct {
 		OrderID int64  `json:"order_id"`
 		Status  string `json:"status"`
+		Reason  string `json:"reason"`
 	}
 	if err := json.Unmarshal(message, &event); err != nil {
 		logrus.Error("Ошибка парсинга Kafka-сообщения:", err)
 		return err
 	}
 
-	// Обновляем заказ в БД
-	if err := h.orderService.UpdateOrderStatus(event.OrderID, event.Status); err != nil {
+	status, err := entity.ParseOrderStatus(event.Status)
+	if err != nil {
+		logrus.Error("Некорректный статус в Kafka-сообщении:", err)
+		return err
+	}
+
+	actor := "kafka"
+	if topic.Topic != nil {
+		actor = entity.KafkaActor(*topic.Topic)
+	}
+
+	// Обновляем заказ в БД. Kafka доставляет сообщения хотя бы один раз, поэтому
+	// повтор уже применённого статуса — не ошибка: остатки ниже списываются снова,
+	// если прошлая попытка упала после смены статуса
+	err = h.orderService.UpdateOrderStatus(ctx, event.OrderID, status, actor, event.Reason, 0)
+	if alreadyApplied(err, status) {
+		logrus.Infof("Заказ %d уже в статусе %s, сообщение доставлено повторно", event.OrderID, status)
+		err = nil
+	}
+	if err != nil {
 		logrus.Error("Ошибка обновления статуса заказа:", err)
 		return err
 	}
 
-	order, err := h.orderService.GetOrderByID(event.OrderID)
+	// Остатки в Product Service списываются только после оплаты
+	if status != entity.OrderStatusPaid {
+		return nil
+	}
+
+	order, err := h.orderService.GetOrderByID(ctx, event.OrderID)
 	if err != nil {
 		logrus.Error("Ошибка получения заказа:", err)
 		return err
 	}
 
-	err = h.productService.UpdateProductStock(order)
+	err = h.productService.UpdateProductStock(ctx, order)
 	if err != nil {
 		logrus.Error("Ошибка обновления остатков товаров:", err)
 		return err
@@ -51,3 +82,9 @@ func (h *Handler) HandleMessage(message []byte, topic kafka.TopicPartition, cn i
 
 	return nil
 }
+
+// alreadyApplied сообщает, что смена статуса отклонена лишь потому, что заказ уже в статусе status
+func alreadyApplied(err error, status entity.OrderStatus) bool {
+	var transition *entity.TransitionError
+	return errors.As(err, &transition) && transition.From == status && transition.To == status
+}
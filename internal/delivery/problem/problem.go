@@ -0,0 +1,51 @@
+// Package problem отправляет ошибки HTTP API в формате application/problem+json (RFC 7807).
+// Им пользуются и обработчики REST, и middleware, чтобы любая ошибка имела одну форму.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"order_service/internal/domainerr"
+)
+
+// ContentType — тип тела ответа об ошибке
+const ContentType = "application/problem+json"
+
+// Коды ошибок HTTP-слоя. Коды доменных ошибок — значения domainerr.Kind.
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = string(domainerr.Forbidden)
+	CodePreconditionFailed   = "precondition_failed"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeInternal             = "internal"
+)
+
+// Problem — тело ответа об ошибке. Code — стабильный код ошибки,
+// по которому клиент различает ошибки, не разбирая текст.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errors — ошибки отдельных полей запроса, если он не прошёл проверку
+	Errors []domainerr.FieldError `json:"errors,omitempty"`
+}
+
+// Write отвечает на запрос r ошибкой со статусом status и кодом code
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	Send(w, r, Problem{Status: status, Code: code, Detail: detail})
+}
+
+// Send дополняет p общими полями и отправляет его
+func Send(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	p.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
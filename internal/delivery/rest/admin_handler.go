@@ -0,0 +1,176 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"order_service/internal/delivery/problem"
+	"order_service/internal/entity"
+	"order_service/internal/middleware"
+	"order_service/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminListOrdersHandler возвращает страницу заказов всех пользователей.
+// Кроме параметров /my-orders принимает user_id и include_deleted=true.
+func (h *OrderHandler) AdminListOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseAdminOrderQuery(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.orderService.ListOrders(r.Context(), query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newOrderPageResponse(page))
+}
+
+// parseAdminOrderQuery добавляет к фильтрам списка заказов фильтры администратора
+func parseAdminOrderQuery(values url.Values) (entity.OrderQuery, error) {
+	query, err := parseOrderQuery(values)
+	if err != nil {
+		return query, err
+	}
+
+	if raw := values.Get("user_id"); raw != "" {
+		userID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			return query, fmt.Errorf("invalid user_id %q", raw)
+		}
+		query.UserID = userID
+	}
+	if raw := values.Get("include_deleted"); raw != "" {
+		if query.IncludeDeleted, err = strconv.ParseBool(raw); err != nil {
+			return query, fmt.Errorf("invalid include_deleted %q", raw)
+		}
+	}
+	return query, nil
+}
+
+// AdminGetOrderHandler возвращает заказ по ID, в том числе мягко удалённый
+func (h *OrderHandler) AdminGetOrderHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
+		return
+	}
+
+	order, err := h.orderService.GetOrderByIDIncludingDeleted(r.Context(), orderID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(order.Version))
+	json.NewEncoder(w).Encode(newOrderResponse(order))
+}
+
+// AdminGetOrderHistoryHandler возвращает историю статусов заказа, в том числе мягко удалённого
+func (h *OrderHandler) AdminGetOrderHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
+		return
+	}
+
+	history, err := h.orderService.GetOrderHistoryIncludingDeleted(r.Context(), orderID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newHistoryResponse(history))
+}
+
+// AdminUpdateStatusHandler вручную меняет статус заказа. Причина обязательна
+// и вместе с ролью и ID администратора попадает в историю статусов. If-Match с ETag
+// из GET /admin/orders/{id} не даёт перезаписать изменение, которого администратор не видел.
+func (h *OrderHandler) AdminUpdateStatusHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
+		return
+	}
+
+	req := struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}{}
+	if err := decodeJSON(r, &req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	status, err := entity.ParseOrderStatus(req.Status)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("invalid status %q", req.Status))
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "reason is required")
+		return
+	}
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	role, _ := middleware.GetRoleFromContext(r.Context())
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	logrus.Infof("Администратор %d меняет статус заказа %d на %s: %s", userID, orderID, status, reason)
+
+	err = h.orderService.UpdateOrderStatus(r.Context(), orderID, status, entity.StaffActor(role, userID), reason, expectedVersion)
+	switch {
+	case errors.Is(err, service.ErrConcurrentModification):
+		problem.Write(w, r, http.StatusPreconditionFailed, problem.CodePreconditionFailed, "order was modified, reload it and retry")
+		return
+	case err != nil:
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminDeleteOrderHandler мягко удаляет заказ
+func (h *OrderHandler) AdminDeleteOrderHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
+		return
+	}
+
+	if err := h.orderService.DeleteOrder(r.Context(), orderID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminRestoreOrderHandler восстанавливает мягко удалённый заказ
+func (h *OrderHandler) AdminRestoreOrderHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
+		return
+	}
+
+	if err := h.orderService.RestoreOrder(r.Context(), orderID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
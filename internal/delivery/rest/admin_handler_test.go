@@ -0,0 +1,258 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"order_service/internal/delivery/problem"
+	"order_service/internal/entity"
+	"order_service/internal/middleware"
+	"order_service/internal/repository"
+	"order_service/internal/service"
+	ServiceMocks "order_service/internal/service/mocks"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/mock/gomock"
+)
+
+// adminRequest возвращает запрос от пользователя с ролью role
+func adminRequest(method, target, body, role string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), middleware.UserIDKey, int64(1))
+	ctx = context.WithValue(ctx, middleware.RoleKey, role)
+	return req.WithContext(ctx)
+}
+
+func TestRequireRole(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := middleware.RequireRole(entity.RoleAdmin)(next)
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected int
+	}{
+		{"Admin", adminRequest(http.MethodGet, "/admin/orders", "", entity.RoleAdmin), http.StatusNoContent},
+		{"OtherRole", adminRequest(http.MethodGet, "/admin/orders", "", "user"), http.StatusForbidden},
+		{"EmptyRole", adminRequest(http.MethodGet, "/admin/orders", "", ""), http.StatusForbidden},
+		{"NoToken", httptest.NewRequest(http.MethodGet, "/admin/orders", nil), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Выполнение
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.req)
+
+			// Проверка
+			if rr.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); tt.expected != http.StatusNoContent && ct != problem.ContentType {
+				t.Errorf("expected %s, got %s", problem.ContentType, ct)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_AdminListOrdersHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := ServiceMocks.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockService)
+
+	t.Run("Filters", func(t *testing.T) {
+		// Подготовка
+		req := adminRequest(http.MethodGet, "/admin/orders?user_id=7&status=paid&include_deleted=true", "", entity.RoleAdmin)
+		rr := httptest.NewRecorder()
+		expectedQuery := entity.OrderQuery{UserID: 7, Statuses: []entity.OrderStatus{entity.OrderStatusPaid}, IncludeDeleted: true}
+		mockService.EXPECT().ListOrders(gomock.Any(), expectedQuery).Return(&entity.OrderPage{Orders: []entity.Order{{ID: 1, UserID: 7}}}, nil)
+
+		// Выполнение
+		handler.AdminListOrdersHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rr.Code)
+		}
+	})
+
+	t.Run("InvalidUserID", func(t *testing.T) {
+		// Подготовка
+		req := adminRequest(http.MethodGet, "/admin/orders?user_id=abc", "", entity.RoleAdmin)
+		rr := httptest.NewRecorder()
+
+		// Выполнение
+		handler.AdminListOrdersHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+}
+
+func TestOrderHandler_AdminUpdateStatusHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := ServiceMocks.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockService)
+
+	tests := []struct {
+		name     string
+		body     string
+		ifMatch  string
+		version  int64 // версия, которую обработчик передаёт сервису
+		err      error
+		call     bool
+		expected int
+	}{
+		{"Success", `{"status":"shipped","reason":"отправлен вручную"}`, "", 0, nil, true, http.StatusNoContent},
+		{"IfMatch", `{"status":"shipped","reason":"отправлен вручную"}`, `"3"`, 3, nil, true, http.StatusNoContent},
+		{"StaleVersion", `{"status":"shipped","reason":"отправлен вручную"}`, `"2"`, 2, service.ErrConcurrentModification, true, http.StatusPreconditionFailed},
+		{"InvalidIfMatch", `{"status":"shipped","reason":"отправлен вручную"}`, "3", 0, nil, false, http.StatusBadRequest},
+		{"MissingReason", `{"status":"shipped","reason":" "}`, "", 0, nil, false, http.StatusBadRequest},
+		{"UnknownStatus", `{"status":"lost","reason":"потерян"}`, "", 0, nil, false, http.StatusBadRequest},
+		{"InvalidTransition", `{"status":"pending","reason":"вернуть"}`, "", 0, &entity.TransitionError{From: entity.OrderStatusPaid, To: entity.OrderStatusPending}, true, http.StatusConflict},
+		{"NotFound", `{"status":"shipped","reason":"отправлен"}`, "", 0, fmt.Errorf("failed to load order: %w", repository.ErrOrderNotFound), true, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			req := mux.SetURLVars(adminRequest(http.MethodPost, "/admin/orders/5/status", tt.body, entity.RoleAdmin), map[string]string{"id": "5"})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			if tt.call {
+				mockService.EXPECT().UpdateOrderStatus(gomock.Any(), int64(5), gomock.Any(), entity.StaffActor(entity.RoleAdmin, 1), gomock.Any(), tt.version).Return(tt.err)
+			}
+
+			// Выполнение
+			handler.AdminUpdateStatusHandler(rr, req)
+
+			// Проверка
+			if rr.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestOrderHandler_AdminDeleteAndRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := ServiceMocks.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockService)
+
+	t.Run("Delete", func(t *testing.T) {
+		// Подготовка
+		req := mux.SetURLVars(adminRequest(http.MethodDelete, "/admin/orders/5", "", entity.RoleAdmin), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+		mockService.EXPECT().DeleteOrder(gomock.Any(), int64(5)).Return(nil)
+
+		// Выполнение
+		handler.AdminDeleteOrderHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", rr.Code)
+		}
+	})
+
+	t.Run("DeleteMissing", func(t *testing.T) {
+		// Подготовка
+		req := mux.SetURLVars(adminRequest(http.MethodDelete, "/admin/orders/5", "", entity.RoleAdmin), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+		mockService.EXPECT().DeleteOrder(gomock.Any(), int64(5)).Return(repository.ErrOrderNotFound)
+
+		// Выполнение
+		handler.AdminDeleteOrderHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("DeletePending", func(t *testing.T) {
+		// Подготовка
+		req := mux.SetURLVars(adminRequest(http.MethodDelete, "/admin/orders/5", "", entity.RoleAdmin), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+		mockService.EXPECT().DeleteOrder(gomock.Any(), int64(5)).Return(repository.ErrOrderPending)
+
+		// Выполнение
+		handler.AdminDeleteOrderHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusConflict {
+			t.Errorf("expected status 409, got %d", rr.Code)
+		}
+	})
+
+	t.Run("GetDeleted", func(t *testing.T) {
+		// Подготовка
+		req := mux.SetURLVars(adminRequest(http.MethodGet, "/admin/orders/5", "", entity.RoleAdmin), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+		deletedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+		order := &entity.Order{ID: 5, UserID: 7, Status: entity.OrderStatusCanceled, Version: 3, DeletedAt: &deletedAt}
+		mockService.EXPECT().GetOrderByIDIncludingDeleted(gomock.Any(), int64(5)).Return(order, nil)
+
+		// Выполнение
+		handler.AdminGetOrderHandler(rr, req)
+
+		// Проверка
+		var got OrderResponse
+		if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if rr.Code != http.StatusOK || got.DeletedAt == "" || rr.Header().Get("ETag") != `"3"` {
+			t.Errorf("expected deleted order with ETag, got %d %+v %q", rr.Code, got, rr.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("HistoryOfDeleted", func(t *testing.T) {
+		// Подготовка
+		req := mux.SetURLVars(adminRequest(http.MethodGet, "/admin/orders/5/history", "", entity.RoleSupport), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+		history := []entity.StatusChange{{ID: 1, OrderID: 5, OldStatus: entity.OrderStatusPending, NewStatus: entity.OrderStatusCanceled, Actor: "user:7"}}
+		mockService.EXPECT().GetOrderHistoryIncludingDeleted(gomock.Any(), int64(5)).Return(history, nil)
+
+		// Выполнение
+		handler.AdminGetOrderHistoryHandler(rr, req)
+
+		// Проверка
+		var got []StatusChangeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if rr.Code != http.StatusOK || len(got) != 1 || got[0].NewStatus != string(entity.OrderStatusCanceled) {
+			t.Errorf("expected history of the deleted order, got %d %+v", rr.Code, got)
+		}
+	})
+
+	t.Run("RestoreNotDeleted", func(t *testing.T) {
+		// Подготовка
+		req := mux.SetURLVars(adminRequest(http.MethodPost, "/admin/orders/5/restore", "", entity.RoleAdmin), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+		mockService.EXPECT().RestoreOrder(gomock.Any(), int64(5)).Return(repository.ErrOrderNotFound)
+
+		// Выполнение
+		handler.AdminRestoreOrderHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"order_service/internal/entity"
+	"order_service/internal/middleware"
+	"order_service/internal/repository"
+	ServiceMocks "order_service/internal/service/mocks"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/mock/gomock"
+)
+
+// update перезаписывает golden-файлы: go test ./internal/delivery/rest -run TestResponseContract -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// assertGolden сравнивает JSON-ответ с testdata/<name>.golden.json
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, body)
+	}
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.WriteFile(path, pretty.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file, run with -update: %v", err)
+	}
+	if !bytes.Equal(pretty.Bytes(), expected) {
+		t.Errorf("response differs from %s:\n%s\nexpected:\n%s", path, pretty.Bytes(), expected)
+	}
+}
+
+func TestResponseContract(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := ServiceMocks.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockService)
+
+	// Время не в UTC и с долями секунды: в ответе оно должно стать RFC 3339 в UTC
+	almaty := time.FixedZone("Asia/Almaty", 5*60*60)
+	createdAt := time.Date(2026, 3, 1, 15, 4, 5, 123456789, almaty)
+	deletedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	order := entity.Order{
+		ID:     5,
+		UserID: 7,
+		Items: []entity.OrderItem{
+			{ID: 11, ProductID: 1, Name: "Кофе", Quantity: 2, Price: kzt(150050)},
+			{ID: 12, ProductID: 3, Name: "Чай", Quantity: 1, Price: kzt(99900)},
+		},
+		TotalPrice: kzt(400000),
+		Status:     entity.OrderStatusPaid,
+		CreatedAt:  createdAt,
+		Version:    2,
+	}
+	deleted := order
+	deleted.ID, deleted.Status, deleted.DeletedAt = 4, entity.OrderStatusCanceled, &deletedAt
+
+	request := func(method, target, body string) *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		ctx := context.WithValue(req.Context(), middleware.UserIDKey, int64(7))
+		ctx = context.WithValue(ctx, middleware.RoleKey, entity.RoleAdmin)
+		return mux.SetURLVars(req.WithContext(ctx), map[string]string{"id": "5"})
+	}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		handle  http.HandlerFunc
+		req     *http.Request
+	}{
+		{
+			name: "order",
+			prepare: func() {
+				mockService.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(&order, nil)
+			},
+			handle: handler.GetOrderByIDHandler,
+			req:    request(http.MethodGet, "/orders/5", ""),
+		},
+		{
+			name: "order_without_created_at",
+			prepare: func() {
+				legacy := order
+				legacy.CreatedAt = time.Time{}
+				mockService.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(&legacy, nil)
+			},
+			handle: handler.GetOrderByIDHandler,
+			req:    request(http.MethodGet, "/orders/5", ""),
+		},
+		{
+			name: "order_page",
+			prepare: func() {
+				page := &entity.OrderPage{Orders: []entity.Order{order, deleted}, NextCursor: "next-page"}
+				mockService.EXPECT().ListOrders(gomock.Any(), gomock.Any()).Return(page, nil)
+			},
+			handle: handler.AdminListOrdersHandler,
+			req:    request(http.MethodGet, "/admin/orders?include_deleted=true", ""),
+		},
+		{
+			name: "order_page_empty",
+			prepare: func() {
+				mockService.EXPECT().GetOrdersByUserID(gomock.Any(), gomock.Any()).Return(&entity.OrderPage{}, nil)
+			},
+			handle: handler.GetMyOrdersHandler,
+			req:    request(http.MethodGet, "/my-orders", ""),
+		},
+		{
+			name: "history",
+			prepare: func() {
+				history := []entity.StatusChange{
+					{ID: 1, OrderID: 5, OldStatus: entity.OrderStatusPending, NewStatus: entity.OrderStatusPaid, Actor: "kafka:payment_events", CreatedAt: createdAt},
+					{ID: 2, OrderID: 5, OldStatus: entity.OrderStatusPaid, NewStatus: entity.OrderStatusShipped, Actor: "admin:101", Reason: "отправлен вручную", CreatedAt: deletedAt},
+				}
+				mockService.EXPECT().GetOrderHistory(gomock.Any(), int64(5)).Return(history, nil)
+			},
+			handle: handler.GetOrderHistoryHandler,
+			req:    request(http.MethodGet, "/orders/5/history", ""),
+		},
+		{
+			name: "payment",
+			prepare: func() {
+				mockService.EXPECT().CreateOrder(gomock.Any(), int64(7), gomock.Any(), gomock.Any()).
+					Return(&entity.PaymentResponse{PaymentURL: "https://pay.example/5"}, nil)
+			},
+			handle: handler.CreateOrderHandler,
+			req:    request(http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":2}],"total_price":{"amount":"3001.00","currency":"KZT"}}`),
+		},
+		{
+			name:    "problem_validation",
+			prepare: func() {},
+			handle:  handler.CreateOrderHandler,
+			req:     request(http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":0},{"product_id":1,"quantity":1}],"total_price":"0"}`),
+		},
+		{
+			name: "problem_not_found",
+			prepare: func() {
+				mockService.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(nil, repository.ErrOrderNotFound)
+			},
+			handle: handler.GetOrderByIDHandler,
+			req:    request(http.MethodGet, "/orders/5", ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			tt.prepare()
+			rr := httptest.NewRecorder()
+
+			// Выполнение
+			tt.handle(rr, tt.req)
+
+			// Проверка
+			assertGolden(t, tt.name, rr.Body.Bytes())
+		})
+	}
+}
@@ -11,7 +11,7 @@ func UserCors(router *mux.Router) http.Handler {
 	return handlers.CORS(
 		handlers.AllowedOrigins([]string{"http://localhost:3000"}),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "Idempotency-Key"}),
 		handlers.AllowCredentials(),
 	)(router)
 }
@@ -0,0 +1,190 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"order_service/internal/entity"
+	"time"
+)
+
+// DTO задают JSON-контракт REST API отдельно от сущностей: поля сущностей можно
+// менять, не ломая клиентов. Имена полей — snake_case, время — RFC 3339 в UTC,
+// суммы — объект {"amount": "100.00", "currency": "KZT"}.
+
+// CreateOrderRequest — тело POST /orders; user_id берётся из токена
+type CreateOrderRequest struct {
+	Items      []CreateOrderItem `json:"items"`
+	TotalPrice MoneyRequest      `json:"total_price"`
+}
+
+// CreateOrderItem — позиция создаваемого заказа. Цену задаёт сервер по данным
+// Product Service; price принимается для совместимости со старыми клиентами.
+type CreateOrderItem struct {
+	ProductID int64        `json:"product_id"`
+	Quantity  int64        `json:"quantity"`
+	Price     MoneyRequest `json:"price"`
+}
+
+// toEntities переводит позиции и сумму проверенного validate запроса в сущности
+func (req CreateOrderRequest) toEntities() ([]entity.OrderItem, entity.Money) {
+	items := make([]entity.OrderItem, len(req.Items))
+	for i, item := range req.Items {
+		price, _ := item.Price.toEntity()
+		items[i] = entity.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity, Price: price}
+	}
+	total, _ := req.TotalPrice.toEntity()
+	return items, total
+}
+
+// MoneyRequest — денежная сумма в запросе: объект {"amount": "100.00", "currency": "KZT"},
+// а для старых клиентов — число или строка в валюте по умолчанию. Сумму проверяет validate.
+type MoneyRequest struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m *MoneyRequest) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '{':
+		type plain MoneyRequest
+		return json.Unmarshal(data, (*plain)(m))
+	case len(data) > 0 && data[0] == '"':
+		return json.Unmarshal(data, &m.Amount)
+	default:
+		// Число берётся по тексту, без float64
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		m.Amount = n.String()
+		return nil
+	}
+}
+
+// set сообщает, передана ли сумма
+func (m MoneyRequest) set() bool {
+	return m.Amount != ""
+}
+
+// toEntity разбирает сумму; без валюты используется entity.DefaultCurrency
+func (m MoneyRequest) toEntity() (entity.Money, error) {
+	if !m.set() {
+		return entity.NewMoney(0, m.Currency), nil
+	}
+	return entity.ParseMoney(m.Amount, m.Currency)
+}
+
+// MoneyResponse — денежная сумма в ответе; amount — строка, чтобы не терять точность
+type MoneyResponse struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// OrderItemResponse — позиция заказа в ответе
+type OrderItemResponse struct {
+	ID        int64         `json:"id"`
+	ProductID int64         `json:"product_id"`
+	Name      string        `json:"name"`
+	Quantity  int64         `json:"quantity"`
+	Price     MoneyResponse `json:"price"`      // цена за единицу
+	LineTotal MoneyResponse `json:"line_total"` // цена × количество
+}
+
+// OrderResponse — заказ в ответе
+type OrderResponse struct {
+	ID         int64               `json:"id"`
+	UserID     int64               `json:"user_id"`
+	Status     string              `json:"status"`
+	Items      []OrderItemResponse `json:"items"`
+	TotalPrice MoneyResponse       `json:"total_price"`
+	CreatedAt  string              `json:"created_at,omitempty"` // нет у старых заказов без времени создания
+	Version    int64               `json:"version"`
+	DeletedAt  string              `json:"deleted_at,omitempty"`
+	ArchivedAt string              `json:"archived_at,omitempty"`
+}
+
+// OrderPageResponse — страница списка заказов
+type OrderPageResponse struct {
+	Orders     []OrderResponse `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// StatusChangeResponse — запись истории статусов заказа
+type StatusChangeResponse struct {
+	ID        int64  `json:"id"`
+	OrderID   int64  `json:"order_id"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+	Actor     string `json:"actor"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// PaymentResponse — ответ на создание заказа
+type PaymentResponse struct {
+	PaymentURL string `json:"payment_url"`
+}
+
+func newMoneyResponse(m entity.Money) MoneyResponse {
+	return MoneyResponse{Amount: m.String(), Currency: m.Currency}
+}
+
+// formatTime возвращает время в RFC 3339 (UTC); нулевое время и nil дают пустую строку
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newOrderResponse(order *entity.Order) OrderResponse {
+	items := make([]OrderItemResponse, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = OrderItemResponse{
+			ID:        item.ID,
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			Price:     newMoneyResponse(item.Price),
+			LineTotal: newMoneyResponse(item.LineTotal()),
+		}
+	}
+	return OrderResponse{
+		ID:         order.ID,
+		UserID:     order.UserID,
+		Status:     string(order.Status),
+		Items:      items,
+		TotalPrice: newMoneyResponse(order.TotalPrice),
+		CreatedAt:  formatTime(&order.CreatedAt),
+		Version:    order.Version,
+		DeletedAt:  formatTime(order.DeletedAt),
+		ArchivedAt: formatTime(order.ArchivedAt),
+	}
+}
+
+func newOrderPageResponse(page *entity.OrderPage) OrderPageResponse {
+	orders := make([]OrderResponse, len(page.Orders))
+	for i := range page.Orders {
+		orders[i] = newOrderResponse(&page.Orders[i])
+	}
+	return OrderPageResponse{Orders: orders, NextCursor: page.NextCursor}
+}
+
+func newHistoryResponse(history []entity.StatusChange) []StatusChangeResponse {
+	changes := make([]StatusChangeResponse, len(history))
+	for i, change := range history {
+		changes[i] = StatusChangeResponse{
+			ID:        change.ID,
+			OrderID:   change.OrderID,
+			OldStatus: string(change.OldStatus),
+			NewStatus: string(change.NewStatus),
+			Actor:     change.Actor,
+			Reason:    change.Reason,
+			CreatedAt: formatTime(&change.CreatedAt),
+		}
+	}
+	return changes
+}
@@ -2,55 +2,92 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"order_service/internal/delivery/problem"
 	"order_service/internal/entity"
 	"order_service/internal/middleware"
 	"order_service/internal/service"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+// maxIdempotencyKeyLength — ограничение длины заголовка Idempotency-Key
+const maxIdempotencyKeyLength = 255
+
 // OrderHandler отвечает за обработку REST-запросов
 type OrderHandler struct {
 	orderService service.OrderServiceInterface
+	limits       OrderLimits
+}
+
+// HandlerOption настраивает OrderHandler
+type HandlerOption func(*OrderHandler)
+
+// WithOrderLimits задаёт ограничения на создаваемый заказ
+func WithOrderLimits(limits OrderLimits) HandlerOption {
+	return func(h *OrderHandler) {
+		h.limits = limits
+	}
 }
 
 // NewOrderHandler создаёт новый обработчик
-func NewOrderHandler(orderService service.OrderServiceInterface) *OrderHandler {
-	return &OrderHandler{orderService: orderService}
+func NewOrderHandler(orderService service.OrderServiceInterface, opts ...HandlerOption) *OrderHandler {
+	h := &OrderHandler{orderService: orderService, limits: DefaultOrderLimits}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // CreateOrderHandler — обработчик для создания продукта
 func (h *OrderHandler) CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "authentication required")
 		return
 	}
 
 	fmt.Println("CreateOrderHandler")
-	// Парсим тело запроса без user_id — он берётся из токена
-	req := struct {
-		Items      []entity.OrderItem `json:"items"`
-		TotalPrice float64            `json:"total_price"`
-	}{}
+	var req CreateOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+	// Все ошибки полей возвращаются одним ответом, до обращения к сервису
+	if err := req.validate(h.limits); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	// Повтор запроса с тем же Idempotency-Key не создаёт второй заказ
+	key := r.Header.Get("Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLength {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "Idempotency-Key is too long")
 		return
 	}
 
-	payment, err := h.orderService.CreateOrder(userID, req.Items, req.TotalPrice)
+	var payment *entity.PaymentResponse
+	var err error
+	items, totalPrice := req.toEntities()
+	if key == "" {
+		payment, err = h.orderService.CreateOrder(r.Context(), userID, items, totalPrice)
+	} else {
+		payment, err = h.orderService.CreateOrderIdempotent(r.Context(), userID, key, items, totalPrice)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(payment)
+	json.NewEncoder(w).Encode(PaymentResponse{PaymentURL: payment.PaymentURL})
 }
 
 // GetOrderByIDHandler — обработчик для получения продукта по ID
@@ -58,68 +95,207 @@ func (h *OrderHandler) GetOrderByIDHandler(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	idStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "missing order ID", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "missing order ID")
 		return
 	}
 
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "invalid order ID", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
 		return
 	}
 
-	order, err := h.orderService.GetOrderByID(id)
+	order, err := h.orderService.GetOrderByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "order not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	w.Header().Set("ETag", versionETag(order.Version))
+	json.NewEncoder(w).Encode(newOrderResponse(order))
 }
 
+// GetOrderHistoryHandler — обработчик для получения истории статусов заказа
+func (h *OrderHandler) GetOrderHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr, ok := vars["id"]
+	if !ok {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "missing order ID")
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
+		return
+	}
+
+	history, err := h.orderService.GetOrderHistory(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newHistoryResponse(history))
+}
+
+// GetMyOrdersHandler возвращает страницу заказов пользователя.
+// Параметры: status (можно несколько через запятую), created_from и created_to
+// (RFC 3339 или YYYY-MM-DD), sort=asc|desc, limit и cursor из next_cursor предыдущей страницы.
 func (h *OrderHandler) GetMyOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "authentication required")
+		return
+	}
+
+	query, err := parseOrderQuery(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
+	query.UserID = userID
 
-	orders, err := h.orderService.GetOrdersByUserID(userID)
+	page, err := h.orderService.GetOrdersByUserID(r.Context(), query)
 	if err != nil {
-		http.Error(w, "Ошибка при получении заказов", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(orders)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newOrderPageResponse(page))
+}
+
+// parseOrderQuery разбирает параметры фильтрации и пагинации списка заказов
+func parseOrderQuery(values url.Values) (entity.OrderQuery, error) {
+	var query entity.OrderQuery
+
+	for _, param := range values["status"] {
+		for _, raw := range strings.Split(param, ",") {
+			status, err := entity.ParseOrderStatus(strings.TrimSpace(raw))
+			if err != nil {
+				return query, fmt.Errorf("invalid status %q", raw)
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	var err error
+	if query.CreatedFrom, err = parseDateParam(values.Get("created_from"), false); err != nil {
+		return query, fmt.Errorf("invalid created_from: %w", err)
+	}
+	if query.CreatedTo, err = parseDateParam(values.Get("created_to"), true); err != nil {
+		return query, fmt.Errorf("invalid created_to: %w", err)
+	}
+	if !query.CreatedFrom.IsZero() && !query.CreatedTo.IsZero() && !query.CreatedFrom.Before(query.CreatedTo) {
+		return query, errors.New("created_from must be before created_to")
+	}
+
+	switch sort := entity.SortDirection(values.Get("sort")); sort {
+	case "", entity.SortAsc, entity.SortDesc:
+		query.Sort = sort
+	default:
+		return query, fmt.Errorf("invalid sort %q, expected asc or desc", sort)
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return query, fmt.Errorf("invalid limit %q", raw)
+		}
+		query.Limit = limit
+	}
+
+	if raw := values.Get("cursor"); raw != "" {
+		if query.After, err = entity.DecodeOrderCursor(raw); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// parseDateParam разбирает время в RFC 3339 или дату YYYY-MM-DD. Дата в конце
+// диапазона (endOfDay) включает весь день, поэтому граница сдвигается на следующие сутки.
+func parseDateParam(raw string, endOfDay bool) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	day, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, errors.New("expected RFC 3339 time or YYYY-MM-DD date")
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
 }
 
 func (h *OrderHandler) CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "authentication required")
 		return
 	}
 
 	vars := mux.Vars(r)
 	orderIDStr, ok := vars["id"]
 	if !ok {
-		http.Error(w, "missing order ID", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "missing order ID")
 		return
 	}
 
 	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "invalid order ID", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid order ID")
 		return
 	}
 
-	err = h.orderService.CancelOrder(userID, orderID)
+	// If-Match с ETag из GET /orders/{id} не даёт отменить заказ, изменённый после чтения
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
 	if err != nil {
-		http.Error(w, "Ошибка при отмене заказа", http.StatusInternalServerError)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	err = h.orderService.CancelOrder(r.Context(), userID, orderID, expectedVersion)
+	switch {
+	case errors.Is(err, service.ErrConcurrentModification):
+		problem.Write(w, r, http.StatusPreconditionFailed, problem.CodePreconditionFailed, "order was modified, reload it and retry")
+		return
+	case err != nil:
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Заказ успешно отменен"))
 }
+
+// versionETag возвращает сильный ETag версии заказа
+func versionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch разбирает заголовок If-Match с ETag версии заказа: "3" или W/"3".
+// Пустой заголовок и * означают запрос без проверки версии и дают 0.
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	tag := strings.TrimPrefix(header, "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil || !strings.HasPrefix(tag, `"`) {
+		return 0, fmt.Errorf("invalid If-Match %q", header)
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match %q", header)
+	}
+	return version, nil
+}
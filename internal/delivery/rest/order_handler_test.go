@@ -4,19 +4,28 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"order_service/internal/authz"
+	"order_service/internal/delivery/problem"
 	"order_service/internal/entity"
 	"order_service/internal/middleware"
+	"order_service/internal/repository"
+	"order_service/internal/service"
 	ServiceMocks "order_service/internal/service/mocks"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/mock/gomock"
 )
 
+func kzt(amount int64) entity.Money {
+	return entity.NewMoney(amount, entity.DefaultCurrency)
+}
+
 func TestOrderHandler_CreateOrderHandler(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -28,15 +37,13 @@ func TestOrderHandler_CreateOrderHandler(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		// Подготовка
-		reqBody := struct {
-			Items      []entity.OrderItem `json:"items"`
-			TotalPrice float64            `json:"total_price"`
-		}{
-			Items: []entity.OrderItem{
-				{ProductID: 1, Quantity: 2, Price: 50.0},
+		reqBody := CreateOrderRequest{
+			Items: []CreateOrderItem{
+				{ProductID: 1, Quantity: 2, Price: MoneyRequest{Amount: "50.00", Currency: "KZT"}},
 			},
-			TotalPrice: 100.0,
+			TotalPrice: MoneyRequest{Amount: "100.00", Currency: "KZT"},
 		}
+		expectedItems := []entity.OrderItem{{ProductID: 1, Quantity: 2, Price: kzt(5000)}}
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
 		rr := httptest.NewRecorder()
@@ -45,7 +52,7 @@ func TestOrderHandler_CreateOrderHandler(t *testing.T) {
 		req = req.WithContext(ctx)
 
 		expectedResponse := &entity.PaymentResponse{PaymentURL: "http://payment.com/link"}
-		mockService.EXPECT().CreateOrder(userID, reqBody.Items, reqBody.TotalPrice).Return(expectedResponse, nil)
+		mockService.EXPECT().CreateOrder(ctx, userID, expectedItems, kzt(10000)).Return(expectedResponse, nil)
 
 		// Выполнение
 		handler.CreateOrderHandler(rr, req)
@@ -54,7 +61,7 @@ func TestOrderHandler_CreateOrderHandler(t *testing.T) {
 		if status := rr.Code; status != http.StatusCreated {
 			t.Errorf("expected status %v, got %v", http.StatusCreated, status)
 		}
-		var response entity.PaymentResponse
+		var response PaymentResponse
 		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
 			t.Fatal(err)
 		}
@@ -63,14 +70,108 @@ func TestOrderHandler_CreateOrderHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("LegacyNumericPrices", func(t *testing.T) {
+		// Подготовка
+		body := []byte(`{"items":[{"product_id":1,"quantity":2,"price":50.0}],"total_price":100.10}`)
+		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
+		req = req.WithContext(ctx)
+
+		expectedItems := []entity.OrderItem{{ProductID: 1, Quantity: 2, Price: kzt(5000)}}
+		mockService.EXPECT().CreateOrder(gomock.Any(), userID, expectedItems, kzt(10010)).Return(&entity.PaymentResponse{PaymentURL: "http://payment.com/link"}, nil)
+
+		// Выполнение
+		handler.CreateOrderHandler(rr, req)
+
+		// Проверка
+		if status := rr.Code; status != http.StatusCreated {
+			t.Errorf("expected status %v, got %v", http.StatusCreated, status)
+		}
+	})
+
+	t.Run("IdempotencyKey", func(t *testing.T) {
+		// Подготовка
+		items := []entity.OrderItem{{ProductID: 1, Quantity: 2, Price: kzt(5000)}}
+		body := []byte(`{"items":[{"product_id":1,"quantity":2,"price":{"amount":"50.00","currency":"KZT"}}],"total_price":{"amount":"100.00","currency":"KZT"}}`)
+		expectedResponse := &entity.PaymentResponse{PaymentURL: "http://payment.com/link"}
+		mockService.EXPECT().CreateOrderIdempotent(gomock.Any(), userID, "key-1", items, kzt(10000)).Return(expectedResponse, nil).Times(2)
+
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
+			req.Header.Set("Idempotency-Key", "key-1")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+			rr := httptest.NewRecorder()
+
+			// Выполнение
+			handler.CreateOrderHandler(rr, req)
+
+			// Проверка
+			if rr.Code != http.StatusCreated {
+				t.Errorf("expected status %v, got %v", http.StatusCreated, rr.Code)
+			}
+			if rr.Body.String() != "{\"payment_url\":\"http://payment.com/link\"}\n" {
+				t.Errorf("unexpected body %q", rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("IdempotencyKeyErrors", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			err      error
+			expected int
+		}{
+			{"Reused", service.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+			{"InProgress", service.ErrIdempotencyKeyInProgress, http.StatusConflict},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Подготовка
+				body := []byte(`{"items":[{"product_id":1,"quantity":2}],"total_price":"100.00"}`)
+				req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
+				req.Header.Set("Idempotency-Key", "key-1")
+				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+				rr := httptest.NewRecorder()
+				mockService.EXPECT().CreateOrderIdempotent(gomock.Any(), userID, "key-1", gomock.Any(), gomock.Any()).Return(nil, tt.err)
+
+				// Выполнение
+				handler.CreateOrderHandler(rr, req)
+
+				// Проверка
+				if rr.Code != tt.expected {
+					t.Errorf("expected status %v, got %v", tt.expected, rr.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("IdempotencyKeyTooLong", func(t *testing.T) {
+		// Подготовка
+		body := []byte(`{"items":[{"product_id":1,"quantity":2}],"total_price":"100.00"}`)
+		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		rr := httptest.NewRecorder()
+
+		// Выполнение
+		handler.CreateOrderHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %v, got %v", http.StatusBadRequest, rr.Code)
+		}
+	})
+
 	t.Run("Unauthorized", func(t *testing.T) {
 		// Подготовка
 		reqBody := struct {
 			Items      []entity.OrderItem `json:"items"`
-			TotalPrice float64            `json:"total_price"`
+			TotalPrice entity.Money       `json:"total_price"`
 		}{
-			Items:      []entity.OrderItem{{ProductID: 1, Quantity: 2, Price: 50.0}},
-			TotalPrice: 100.0,
+			Items:      []entity.OrderItem{{ProductID: 1, Quantity: 2, Price: kzt(5000)}},
+			TotalPrice: kzt(10000),
 		}
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
@@ -101,6 +202,91 @@ func TestOrderHandler_CreateOrderHandler(t *testing.T) {
 			t.Errorf("expected status %v, got %v", http.StatusBadRequest, status)
 		}
 	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		// Подготовка
+		body := `{"items":[{"product_id":1,"quantity":1}],"total_price":"50.00","user_id":2}`
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		rr := httptest.NewRecorder()
+
+		// Выполнение
+		handler.CreateOrderHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `unknown field \"user_id\"`) {
+			t.Errorf("expected unknown field to be rejected, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("ValidationErrors", func(t *testing.T) {
+		// Подготовка: ограничения из конфигурации, сервис не вызывается
+		limited := NewOrderHandler(mockService, WithOrderLimits(OrderLimits{MaxItems: 3, MaxQuantity: 5}))
+		body := `{"items":[
+			{"product_id":1,"quantity":2},
+			{"product_id":0,"quantity":1},
+			{"product_id":1,"quantity":0},
+			{"product_id":4,"quantity":6}
+		],"total_price":"0"}`
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		rr := httptest.NewRecorder()
+
+		// Выполнение
+		limited.CreateOrderHandler(rr, req)
+
+		// Проверка
+		if rr.Code != http.StatusBadRequest || rr.Header().Get("Content-Type") != problem.ContentType {
+			t.Fatalf("expected 400 problem, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+		}
+		var resp problem.Problem
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, field := range resp.Errors {
+			got = append(got, field.String())
+		}
+		expected := []string{
+			"items must contain at most 3 items",
+			"items[1].product_id must be > 0",
+			"items[2].product_id duplicates items[0]",
+			"items[2].quantity must be > 0",
+			"items[3].quantity must be <= 5",
+			"total_price must be > 0",
+		}
+		if resp.Code != "invalid_argument" || strings.Join(got, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("expected all field errors %q, got %q (%s)", expected, got, resp.Code)
+		}
+	})
+
+	t.Run("InvalidAmounts", func(t *testing.T) {
+		// Подготовка: суммы с лишними знаками — ошибки полей, а не ошибка разбора тела
+		body := `{"items":[{"product_id":1,"quantity":2,"price":50.001}],"total_price":{"amount":"100.001","currency":"KZT"}}`
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+		rr := httptest.NewRecorder()
+
+		// Выполнение
+		handler.CreateOrderHandler(rr, req)
+
+		// Проверка
+		var resp problem.Problem
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, field := range resp.Errors {
+			got = append(got, field.String())
+		}
+		expected := []string{
+			"items[0].price must be a decimal amount with at most 2 fraction digits",
+			"total_price must be a decimal amount with at most 2 fraction digits",
+		}
+		if rr.Code != http.StatusBadRequest || strings.Join(got, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("expected 400 with %q, got %d %q", expected, rr.Code, got)
+		}
+	})
 }
 
 func TestOrderHandler_GetOrderByIDHandler(t *testing.T) {
@@ -115,11 +301,12 @@ func TestOrderHandler_GetOrderByIDHandler(t *testing.T) {
 		expectedOrder := &entity.Order{
 			ID:         1,
 			UserID:     1,
-			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: 50.0}},
-			TotalPrice: 100.0,
+			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: kzt(5000)}},
+			TotalPrice: kzt(10000),
 			Status:     "pending",
+			Version:    3,
 		}
-		mockService.EXPECT().GetOrderByID(int64(1)).Return(expectedOrder, nil)
+		mockService.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(expectedOrder, nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
 		rr := httptest.NewRecorder()
@@ -133,13 +320,16 @@ func TestOrderHandler_GetOrderByIDHandler(t *testing.T) {
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("expected status %v, got %v", http.StatusOK, status)
 		}
-		var response entity.Order
+		var response OrderResponse
 		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
 			t.Fatal(err)
 		}
-		if response.ID != expectedOrder.ID || response.TotalPrice != expectedOrder.TotalPrice {
+		if response.ID != expectedOrder.ID || response.TotalPrice != (MoneyResponse{Amount: "100.00", Currency: "KZT"}) {
 			t.Errorf("expected order %v, got %v", expectedOrder, response)
 		}
+		if etag := rr.Header().Get("ETag"); etag != `"3"` {
+			t.Errorf("expected ETag \"3\", got %q", etag)
+		}
 	})
 
 	t.Run("InvalidID", func(t *testing.T) {
@@ -160,7 +350,7 @@ func TestOrderHandler_GetOrderByIDHandler(t *testing.T) {
 
 	t.Run("NotFound", func(t *testing.T) {
 		// Подготовка
-		mockService.EXPECT().GetOrderByID(int64(999)).Return(nil, errors.New("order not found"))
+		mockService.EXPECT().GetOrderByID(gomock.Any(), int64(999)).Return(nil, repository.ErrOrderNotFound)
 
 		req := httptest.NewRequest(http.MethodGet, "/orders/999", nil)
 		rr := httptest.NewRecorder()
@@ -175,6 +365,95 @@ func TestOrderHandler_GetOrderByIDHandler(t *testing.T) {
 			t.Errorf("expected status %v, got %v", http.StatusNotFound, status)
 		}
 	})
+
+	t.Run("NotOwner", func(t *testing.T) {
+		// Подготовка: чужой заказ неотличим от отсутствующего
+		denied := &authz.DeniedError{Principal: authz.Principal{UserID: 8}, Action: authz.ActionView, OwnerID: 7, Hidden: true}
+		mockService.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(nil, denied)
+
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/orders/5", nil), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+
+		// Выполнение
+		handler.GetOrderByIDHandler(rr, req)
+
+		// Проверка
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("expected status %v, got %v", http.StatusNotFound, status)
+		}
+		if strings.Contains(rr.Body.String(), "access denied") {
+			t.Errorf("expected response not to reveal the order, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		// Подготовка
+		denied := &authz.DeniedError{Principal: authz.Principal{UserID: 7}, Action: authz.ActionView, OwnerID: 7}
+		mockService.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(nil, denied)
+
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/orders/5", nil), map[string]string{"id": "5"})
+		rr := httptest.NewRecorder()
+
+		// Выполнение
+		handler.GetOrderByIDHandler(rr, req)
+
+		// Проверка
+		if status := rr.Code; status != http.StatusForbidden {
+			t.Errorf("expected status %v, got %v", http.StatusForbidden, status)
+		}
+	})
+}
+
+func TestOrderHandler_GetOrderHistoryHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := ServiceMocks.NewMockOrderServiceInterface(ctrl)
+	handler := NewOrderHandler(mockService)
+
+	t.Run("Success", func(t *testing.T) {
+		// Подготовка
+		history := []entity.StatusChange{
+			{ID: 1, OrderID: 1, OldStatus: entity.OrderStatusPending, NewStatus: entity.OrderStatusPaid, Actor: "kafka:payment_events"},
+		}
+		mockService.EXPECT().GetOrderHistory(gomock.Any(), int64(1)).Return(history, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/orders/1/history", nil)
+		rr := httptest.NewRecorder()
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+		// Выполнение
+		handler.GetOrderHistoryHandler(rr, req)
+
+		// Проверка
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("expected status %v, got %v", http.StatusOK, status)
+		}
+		var response []StatusChangeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response) != 1 || response[0].NewStatus != string(entity.OrderStatusPaid) || response[0].Actor != "kafka:payment_events" {
+			t.Errorf("expected history %v, got %v", history, response)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		// Подготовка
+		mockService.EXPECT().GetOrderHistory(gomock.Any(), int64(999)).Return(nil, repository.ErrOrderNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/orders/999/history", nil)
+		rr := httptest.NewRecorder()
+		req = mux.SetURLVars(req, map[string]string{"id": "999"})
+
+		// Выполнение
+		handler.GetOrderHistoryHandler(rr, req)
+
+		// Проверка
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("expected status %v, got %v", http.StatusNotFound, status)
+		}
+	})
 }
 
 func TestOrderHandler_GetMyOrdersHandler(t *testing.T) {
@@ -189,12 +468,24 @@ func TestOrderHandler_GetMyOrdersHandler(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		// Подготовка
 		expectedOrders := []entity.Order{
-			{ID: 1, UserID: userID, TotalPrice: 100.0, Status: "pending"},
-			{ID: 2, UserID: userID, TotalPrice: 200.0, Status: "paid"},
+			{ID: 1, UserID: userID, TotalPrice: kzt(10000), Status: "pending"},
+			{ID: 2, UserID: userID, TotalPrice: kzt(20000), Status: "paid"},
+		}
+		cursor := entity.OrderCursor{CreatedAt: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), ID: 7}
+		expectedQuery := entity.OrderQuery{
+			UserID:      userID,
+			Statuses:    []entity.OrderStatus{entity.OrderStatusPending, entity.OrderStatusPaid},
+			CreatedFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatedTo:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			Sort:        entity.SortAsc,
+			Limit:       2,
+			After:       &cursor,
 		}
-		mockService.EXPECT().GetOrdersByUserID(userID).Return(expectedOrders, nil)
+		page := &entity.OrderPage{Orders: expectedOrders, NextCursor: "next"}
+		mockService.EXPECT().GetOrdersByUserID(gomock.Any(), expectedQuery).Return(page, nil)
 
-		req := httptest.NewRequest(http.MethodGet, "/my-orders", nil)
+		target := "/my-orders?status=pending,paid&created_from=2026-01-01&created_to=2026-01-31&sort=asc&limit=2&cursor=" + cursor.Encode()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
 		rr := httptest.NewRecorder()
 		ctx := context.WithValue(req.Context(), middleware.UserIDKey, userID)
 		req = req.WithContext(ctx)
@@ -206,12 +497,37 @@ func TestOrderHandler_GetMyOrdersHandler(t *testing.T) {
 		if status := rr.Code; status != http.StatusOK {
 			t.Errorf("expected status %v, got %v", http.StatusOK, status)
 		}
-		var response []entity.Order
+		var response entity.OrderPage
 		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
 			t.Fatal(err)
 		}
-		if len(response) != 2 {
-			t.Errorf("expected 2 orders, got %d", len(response))
+		if len(response.Orders) != 2 || response.NextCursor != "next" {
+			t.Errorf("expected 2 orders and next cursor, got %+v", response)
+		}
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		for _, query := range []string{
+			"status=unknown",
+			"created_from=yesterday",
+			"created_from=2026-02-01&created_to=2026-01-01",
+			"sort=up",
+			"limit=0",
+			"limit=ten",
+			"cursor=not-a-cursor",
+		} {
+			// Подготовка
+			req := httptest.NewRequest(http.MethodGet, "/my-orders?"+query, nil)
+			rr := httptest.NewRecorder()
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+
+			// Выполнение
+			handler.GetMyOrdersHandler(rr, req)
+
+			// Проверка
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("%s: expected status %v, got %v", query, http.StatusBadRequest, status)
+			}
 		}
 	})
 
@@ -241,7 +557,7 @@ func TestOrderHandler_CancelOrderHandler(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		// Подготовка
-		mockService.EXPECT().CancelOrder(userID, int64(1)).Return(nil)
+		mockService.EXPECT().CancelOrder(gomock.Any(), userID, int64(1), int64(0)).Return(nil)
 
 		req := httptest.NewRequest(http.MethodPost, "/orders/1/cancel", nil)
 		rr := httptest.NewRecorder()
@@ -259,6 +575,43 @@ func TestOrderHandler_CancelOrderHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("IfMatch", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			ifMatch  string
+			version  int64
+			err      error
+			expected int
+		}{
+			{name: "CurrentVersion", ifMatch: `"2"`, version: 2, expected: http.StatusOK},
+			{name: "WeakETag", ifMatch: `W/"2"`, version: 2, expected: http.StatusOK},
+			{name: "StaleVersion", ifMatch: `"1"`, version: 1, err: service.ErrConcurrentModification, expected: http.StatusPreconditionFailed},
+			{name: "Invalid", ifMatch: "2", expected: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Подготовка
+				if tt.version != 0 {
+					mockService.EXPECT().CancelOrder(gomock.Any(), userID, int64(1), tt.version).Return(tt.err)
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/orders/1/cancel", nil)
+				req.Header.Set("If-Match", tt.ifMatch)
+				rr := httptest.NewRecorder()
+				req = mux.SetURLVars(req, map[string]string{"id": "1"})
+				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+
+				// Выполнение
+				handler.CancelOrderHandler(rr, req)
+
+				// Проверка
+				if status := rr.Code; status != tt.expected {
+					t.Errorf("expected status %v, got %v", tt.expected, status)
+				}
+			})
+		}
+	})
+
 	t.Run("Unauthorized", func(t *testing.T) {
 		// Подготовка
 		req := httptest.NewRequest(http.MethodPost, "/orders/1/cancel", nil)
@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"order_service/internal/delivery/problem"
+	"order_service/internal/domainerr"
+	"order_service/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// kindStatus — HTTP-статус ответа для каждого вида доменной ошибки
+var kindStatus = map[domainerr.Kind]int{
+	domainerr.NotFound:          http.StatusNotFound,
+	domainerr.InsufficientStock: http.StatusConflict,
+	domainerr.DuplicateProduct:  http.StatusUnprocessableEntity,
+	domainerr.InvalidTransition: http.StatusConflict,
+	domainerr.Forbidden:         http.StatusForbidden,
+	domainerr.Conflict:          http.StatusConflict,
+	domainerr.InvalidArgument:   http.StatusBadRequest,
+}
+
+// writeError переводит ошибку сервиса в ответ problem+json. Текст доменной ошибки
+// уходит клиенту, внутренняя ошибка только логируется и отдаётся как 500.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	// Повтор ключа с другим телом — ошибка клиента, а не конфликт с другим запросом
+	if errors.Is(err, service.ErrIdempotencyKeyReused) {
+		problem.Write(w, r, http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused, err.Error())
+		return
+	}
+
+	kind, ok := domainerr.KindOf(err)
+	if !ok {
+		logrus.Errorf("Ошибка обработки запроса %s %s: %v", r.Method, r.URL.Path, err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeInternal, "")
+		return
+	}
+	p := problem.Problem{Status: kindStatus[kind], Code: string(kind), Detail: err.Error()}
+	var validation *domainerr.ValidationError
+	if errors.As(err, &validation) {
+		p.Errors = validation.Fields
+	}
+	problem.Send(w, r, p)
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"order_service/internal/authz"
+	"order_service/internal/delivery/problem"
+	"order_service/internal/domainerr"
+	"order_service/internal/entity"
+	"order_service/internal/repository"
+	"order_service/internal/service"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"OrderNotFound", fmt.Errorf("failed to load order: %w", repository.ErrOrderNotFound), http.StatusNotFound, "not_found"},
+		{"HiddenDenial", &authz.DeniedError{Action: authz.ActionView, OwnerID: 7, Hidden: true}, http.StatusNotFound, "not_found"},
+		{"Forbidden", &authz.DeniedError{Action: authz.ActionDelete}, http.StatusForbidden, "forbidden"},
+		{"InsufficientStock", domainerr.New(domainerr.InsufficientStock, "not enough stock for product 1"), http.StatusConflict, "insufficient_stock"},
+		{"DuplicateProduct", domainerr.New(domainerr.DuplicateProduct, "duplicate product id: 1"), http.StatusUnprocessableEntity, "duplicate_product"},
+		{"InvalidTransition", &entity.TransitionError{From: entity.OrderStatusPaid, To: entity.OrderStatusPending}, http.StatusConflict, "invalid_transition"},
+		{"Conflict", service.ErrConcurrentModification, http.StatusConflict, "conflict"},
+		{"IdempotencyKeyReused", service.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+		{"Internal", fmt.Errorf("failed to reserve stock: %w", sql.ErrConnDone), http.StatusInternalServerError, "internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			req := httptest.NewRequest(http.MethodGet, "/orders/5", nil)
+			rr := httptest.NewRecorder()
+
+			// Выполнение
+			writeError(rr, req, tt.err)
+
+			// Проверка
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Errorf("expected %s, got %s", problem.ContentType, ct)
+			}
+			var body problem.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.code || body.Status != tt.status || body.Instance != "/orders/5" {
+				t.Errorf("unexpected problem %+v", body)
+			}
+			if tt.code == "internal" && body.Detail != "" {
+				t.Errorf("expected internal error text to stay in logs, got %q", body.Detail)
+			}
+			if tt.code != "internal" && body.Detail == "" {
+				t.Error("expected detail for a domain error")
+			}
+		})
+	}
+
+	if !errors.Is(repository.ErrOrderNotFound, sql.ErrNoRows) {
+		t.Error("expected ErrOrderNotFound to keep matching sql.ErrNoRows")
+	}
+}
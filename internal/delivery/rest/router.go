@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"order_service/internal/entity"
 	"order_service/internal/middleware"
 
 	"github.com/gorilla/mux"
@@ -8,6 +9,7 @@ import (
 
 func NewRouter(orderHandler *OrderHandler) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(middleware.ReadYourWrites)
 	// Группа маршрутов, защищённых JWT
 	api := r.PathPrefix("/").Subrouter()
 	api.Use(middleware.JWTMiddleware)
@@ -15,5 +17,17 @@ func NewRouter(orderHandler *OrderHandler) *mux.Router {
 	api.HandleFunc("/orders/{id}", orderHandler.GetOrderByIDHandler).Methods("GET")
 	api.HandleFunc("/my-orders", orderHandler.GetMyOrdersHandler).Methods("GET")
 	api.HandleFunc("/orders/{id}/cancel", orderHandler.CancelOrderHandler).Methods("POST")
+	api.HandleFunc("/orders/{id}/history", orderHandler.GetOrderHistoryHandler).Methods("GET")
+
+	// Заказы всех пользователей: поддержка их читает, администратор ещё и меняет.
+	// Права на конкретную операцию проверяет сервис.
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireRole(entity.RoleAdmin, entity.RoleSupport))
+	admin.HandleFunc("/orders", orderHandler.AdminListOrdersHandler).Methods("GET")
+	admin.HandleFunc("/orders/{id}", orderHandler.AdminGetOrderHandler).Methods("GET")
+	admin.HandleFunc("/orders/{id}", orderHandler.AdminDeleteOrderHandler).Methods("DELETE")
+	admin.HandleFunc("/orders/{id}/status", orderHandler.AdminUpdateStatusHandler).Methods("POST")
+	admin.HandleFunc("/orders/{id}/restore", orderHandler.AdminRestoreOrderHandler).Methods("POST")
+	admin.HandleFunc("/orders/{id}/history", orderHandler.AdminGetOrderHistoryHandler).Methods("GET")
 	return r
 }
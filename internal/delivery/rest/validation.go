@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"order_service/internal/domainerr"
+)
+
+// OrderLimits — ограничения на создаваемый заказ, которые проверяются до вызова сервиса
+type OrderLimits struct {
+	MaxItems    int   // сколько позиций может быть в заказе; 0 — без ограничения
+	MaxQuantity int64 // сколько единиц товара может быть в позиции; 0 — без ограничения
+}
+
+// DefaultOrderLimits — ограничения заказа, если не задано иное
+var DefaultOrderLimits = OrderLimits{MaxItems: 50, MaxQuantity: 1000}
+
+// validate проверяет запрос целиком и возвращает *domainerr.ValidationError
+// со всеми ошибками полей. Наличие товаров проверяет сервис.
+func (req CreateOrderRequest) validate(limits OrderLimits) error {
+	var v domainerr.ValidationError
+
+	switch {
+	case len(req.Items) == 0:
+		v.Add("items", "must not be empty")
+	case limits.MaxItems > 0 && len(req.Items) > limits.MaxItems:
+		v.Add("items", "must contain at most %d items", limits.MaxItems)
+	}
+
+	seen := make(map[int64]int, len(req.Items))
+	for i, item := range req.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.ProductID <= 0 {
+			v.Add(field+".product_id", "must be > 0")
+		} else if first, ok := seen[item.ProductID]; ok {
+			v.Add(field+".product_id", "duplicates items[%d]", first)
+		} else {
+			seen[item.ProductID] = i
+		}
+
+		switch {
+		case item.Quantity <= 0:
+			v.Add(field+".quantity", "must be > 0")
+		case limits.MaxQuantity > 0 && item.Quantity > limits.MaxQuantity:
+			v.Add(field+".quantity", "must be <= %d", limits.MaxQuantity)
+		}
+
+		// Цену задаёт сервер, но переданная клиентом должна быть корректной суммой
+		if _, err := item.Price.toEntity(); err != nil {
+			v.Add(field+".price", "must be a decimal amount with at most 2 fraction digits")
+		}
+	}
+
+	switch total, err := req.TotalPrice.toEntity(); {
+	case err != nil:
+		v.Add("total_price", "must be a decimal amount with at most 2 fraction digits")
+	case total.Amount <= 0:
+		v.Add("total_price", "must be > 0")
+	}
+	return v.Err()
+}
+
+// decodeJSON разбирает тело запроса в dst. Неизвестные поля и данные после
+// JSON-объекта считаются ошибкой, чтобы опечатки клиента не терялись молча.
+func decodeJSON(r *http.Request, dst interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return errors.New("invalid request body: expected a single JSON object")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package domainerr описывает ошибки предметной области, общие для сервиса
+// и репозиториев. Вид ошибки — её стабильный код, по нему транспорт выбирает
+// ответ клиенту. Тексты ошибок пишутся по-английски: они уходят клиенту.
+package domainerr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind — вид доменной ошибки. Значение служит стабильным кодом ошибки
+// и само является ошибкой, поэтому проверяется через errors.Is(err, NotFound).
+type Kind string
+
+const (
+	NotFound          Kind = "not_found"
+	InsufficientStock Kind = "insufficient_stock"
+	DuplicateProduct  Kind = "duplicate_product"
+	InvalidTransition Kind = "invalid_transition"
+	Forbidden         Kind = "forbidden"
+	Conflict          Kind = "conflict"
+	InvalidArgument   Kind = "invalid_argument"
+)
+
+func (k Kind) Error() string {
+	return strings.ReplaceAll(string(k), "_", " ")
+}
+
+// Error — доменная ошибка с сообщением для клиента
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error // исходная ошибка, если есть
+}
+
+// New возвращает ошибку вида kind с сообщением по формату
+func New(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap возвращает ошибку вида kind, для которой errors.Is и errors.As видят и err
+func Wrap(kind Kind, err error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// KindOf возвращает вид первой доменной ошибки в цепочке err.
+// ok == false означает, что ошибка не доменная, то есть внутренняя.
+func KindOf(err error) (kind Kind, ok bool) {
+	ok = errors.As(err, &kind)
+	return kind, ok
+}
@@ -0,0 +1,57 @@
+package domainerr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	notFound := Wrap(NotFound, sql.ErrNoRows, "order not found")
+
+	tests := []struct {
+		name string
+		err  error
+		kind Kind
+		ok   bool
+	}{
+		{"Kind", Conflict, Conflict, true},
+		{"Error", New(DuplicateProduct, "duplicate product id: %d", 3), DuplicateProduct, true},
+		{"Wrapped", fmt.Errorf("failed to load order: %w", notFound), NotFound, true},
+		{"Validation", &ValidationError{Fields: []FieldError{{Field: "items", Message: "must not be empty"}}}, InvalidArgument, true},
+		{"Internal", errors.New("connection refused"), "", false},
+		{"Nil", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Выполнение
+			kind, ok := KindOf(tt.err)
+
+			// Проверка
+			if kind != tt.kind || ok != tt.ok {
+				t.Errorf("expected %q, %v, got %q, %v", tt.kind, tt.ok, kind, ok)
+			}
+		})
+	}
+
+	if !errors.Is(notFound, NotFound) || !errors.Is(notFound, sql.ErrNoRows) {
+		t.Errorf("expected wrapped error to match its kind and cause")
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	var v ValidationError
+	if v.Err() != nil {
+		t.Fatal("expected no error without fields")
+	}
+
+	v.Add("items[0].quantity", "must be > 0")
+	v.Add("items[2].product_id", "duplicates items[0]")
+
+	err := v.Err()
+	expected := "validation failed: items[0].quantity must be > 0; items[2].product_id duplicates items[0]"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,47 @@
+package domainerr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError — ошибка одного поля запроса. Field — путь к полю, например items[2].quantity.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationError собирает все ошибки полей запроса, чтобы клиент получил их
+// за один ответ. Её вид — InvalidArgument.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add добавляет ошибку поля field с сообщением по формату
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err возвращает e, если ошибки есть, и nil, если запрос корректен
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.String()
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return InvalidArgument
+}
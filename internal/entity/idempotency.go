@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyRecord — сохранённый ключ Idempotency-Key пользователя.
+// Response пуст, пока первый запрос с этим ключом ещё выполняется.
+type IdempotencyRecord struct {
+	UserID      int64
+	Key         string
+	RequestHash string
+	Response    json.RawMessage
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,227 @@
+package entity
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"order_service/internal/domainerr"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency — валюта, в которой хранятся заказы, если явно не указана другая
+const DefaultCurrency = "KZT"
+
+// minorUnits — количество минимальных единиц в одной основной (тиын в тенге, центы в долларе).
+// Все поддерживаемые валюты имеют две дробные цифры, как и колонки DECIMAL(10,2).
+const minorUnits = 100
+
+var (
+	ErrInvalidAmount    = domainerr.New(domainerr.InvalidArgument, "invalid money amount")
+	ErrCurrencyMismatch = domainerr.New(domainerr.InvalidArgument, "currency mismatch")
+)
+
+// Money — точная денежная сумма в минимальных единицах валюты.
+//
+// Правила округления:
+//   - сумма строки (Mul) считается точно, округление не требуется;
+//   - скидка (Discount) считается от суммы строки и округляется до минимальной
+//     единицы по банковскому правилу (половина — к чётному);
+//   - значения из float64 (MoneyFromFloat) округляются до минимальной единицы,
+//     половина — от нуля.
+type Money struct {
+	Amount   int64  // сумма в минимальных единицах
+	Currency string // код валюты ISO 4217
+}
+
+// NewMoney создаёт сумму из минимальных единиц
+func NewMoney(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MoneyFromFloat переводит значение с плавающей точкой в Money.
+// Используется только для устаревших полей double в контрактах.
+func MoneyFromFloat(amount float64, currency string) Money {
+	return NewMoney(int64(math.Round(amount*minorUnits)), currency)
+}
+
+// ParseMoney разбирает десятичную строку вида "12.34".
+// Более двух дробных цифр считается ошибкой, а не округляется.
+func ParseMoney(s, currency string) (Money, error) {
+	raw := strings.TrimSpace(s)
+	negative := strings.HasPrefix(raw, "-")
+	raw = strings.TrimPrefix(raw, "-")
+
+	whole, frac, hasFrac := strings.Cut(raw, ".")
+	if whole == "" || (hasFrac && frac == "") || len(frac) > 2 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	units, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	cents, err := strconv.ParseUint(frac, 10, 8)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if units > math.MaxInt64/minorUnits {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	amount := int64(units)*minorUnits + int64(cents)
+	if negative {
+		amount = -amount
+	}
+	return NewMoney(amount, currency), nil
+}
+
+// String возвращает сумму в виде десятичной строки без валюты, например "12.34"
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/minorUnits, amount%minorUnits)
+}
+
+// IsZero сообщает, равна ли сумма нулю
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Float64 возвращает сумму в основных единицах для устаревших полей double
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / minorUnits
+}
+
+// Add складывает суммы в одной валюте
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Mul возвращает сумму строки заказа: цена за единицу, умноженная на количество
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Discount возвращает размер скидки в basisPoints (1% = 100) от суммы,
+// округлённый до минимальной единицы по банковскому правилу
+func (m Money) Discount(basisPoints int64) Money {
+	return Money{Amount: divRoundHalfEven(m.Amount*basisPoints, 10000), Currency: m.Currency}
+}
+
+// ApplyDiscount возвращает сумму за вычетом скидки в basisPoints
+func (m Money) ApplyDiscount(basisPoints int64) Money {
+	return Money{Amount: m.Amount - m.Discount(basisPoints).Amount, Currency: m.Currency}
+}
+
+// divRoundHalfEven делит a на положительное b с округлением половины к чётному
+func divRoundHalfEven(a, b int64) int64 {
+	q, r := a/b, a%b
+	if r < 0 {
+		r = -r
+	}
+	switch {
+	case 2*r > b, 2*r == b && q%2 != 0:
+		if a < 0 {
+			return q - 1
+		}
+		return q + 1
+	}
+	return q
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON кодирует сумму как {"amount": "12.34", "currency": "KZT"}.
+// Сумма передаётся строкой, чтобы клиенты не теряли точность на float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+// UnmarshalJSON принимает объект {"amount", "currency"}, а также число или
+// строку в валюте по умолчанию. Число разбирается по тексту, без float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '{':
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(v.Amount, v.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(s, DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	default:
+		parsed, err := ParseMoney(string(data), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+}
+
+// Value сохраняет сумму в колонку DECIMAL
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan читает сумму из колонки DECIMAL, сохраняя уже заданную валюту
+func (m *Money) Scan(src interface{}) error {
+	currency := m.Currency
+	var (
+		parsed Money
+		err    error
+	)
+	switch v := src.(type) {
+	case []byte:
+		parsed, err = ParseMoney(string(v), currency)
+	case string:
+		parsed, err = ParseMoney(v, currency)
+	case int64:
+		parsed = NewMoney(v*minorUnits, currency)
+	case float64:
+		parsed = MoneyFromFloat(v, currency)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,171 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+	}{
+		{"12.34", 1234},
+		{"12.3", 1230},
+		{"12", 1200},
+		{"0.05", 5},
+		{"-1.50", -150},
+		{"100.00", 10000},
+	}
+	for _, tc := range cases {
+		m, err := ParseMoney(tc.in, "")
+		if err != nil {
+			t.Errorf("ParseMoney(%q): unexpected error %v", tc.in, err)
+			continue
+		}
+		if m.Amount != tc.want || m.Currency != DefaultCurrency {
+			t.Errorf("ParseMoney(%q) = %+v, want %d %s", tc.in, m, tc.want, DefaultCurrency)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1.234", "1.", ".5", "1e2", "+1", "--1"} {
+		if _, err := ParseMoney(in, ""); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseMoney(%q): expected ErrInvalidAmount, got %v", in, err)
+		}
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	cases := map[int64]string{0: "0.00", 5: "0.05", 1234: "12.34", -150: "-1.50"}
+	for amount, want := range cases {
+		if got := NewMoney(amount, "").String(); got != want {
+			t.Errorf("String(%d) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	t.Run("LineTotalIsExact", func(t *testing.T) {
+		// 0.1 × 3 в float64 даёт 0.30000000000000004
+		item := OrderItem{Quantity: 3, Price: NewMoney(10, "")}
+		if got := item.LineTotal(); got.Amount != 30 {
+			t.Errorf("expected 30, got %d", got.Amount)
+		}
+	})
+
+	t.Run("SumOfManyItemsIsExact", func(t *testing.T) {
+		total := NewMoney(0, "")
+		for i := 0; i < 1000; i++ {
+			var err error
+			total, err = total.Add(NewMoney(1, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if total.String() != "10.00" {
+			t.Errorf("expected 10.00, got %s", total)
+		}
+	})
+
+	t.Run("CurrencyMismatch", func(t *testing.T) {
+		_, err := NewMoney(100, "KZT").Add(NewMoney(100, "USD"))
+		if !errors.Is(err, ErrCurrencyMismatch) {
+			t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+		}
+	})
+
+	t.Run("DiscountRoundsHalfToEven", func(t *testing.T) {
+		cases := []struct {
+			amount, bps, want int64
+		}{
+			{1000, 1000, 100}, // 10% от 10.00 = 1.00
+			{25, 1000, 2},     // 0.025 -> 0.02
+			{35, 1000, 4},     // 0.035 -> 0.04
+			{26, 1000, 3},     // 0.026 -> 0.03
+			{-35, 1000, -4},   // -0.035 -> -0.04
+			{999, 3333, 333},  // 332.9667 -> 333
+		}
+		for _, tc := range cases {
+			if got := NewMoney(tc.amount, "").Discount(tc.bps); got.Amount != tc.want {
+				t.Errorf("Discount(%d, %d) = %d, want %d", tc.amount, tc.bps, got.Amount, tc.want)
+			}
+		}
+		if got := NewMoney(1000, "").ApplyDiscount(1500); got.Amount != 850 {
+			t.Errorf("ApplyDiscount = %d, want 850", got.Amount)
+		}
+	})
+
+	t.Run("FromFloatRoundsHalfAwayFromZero", func(t *testing.T) {
+		if got := MoneyFromFloat(0.1+0.2, ""); got.Amount != 30 {
+			t.Errorf("expected 30, got %d", got.Amount)
+		}
+		if got := MoneyFromFloat(0.125, ""); got.Amount != 13 {
+			t.Errorf("expected 13, got %d", got.Amount)
+		}
+		if got := MoneyFromFloat(-0.125, ""); got.Amount != -13 {
+			t.Errorf("expected -13, got %d", got.Amount)
+		}
+	})
+}
+
+func TestMoney_JSON(t *testing.T) {
+	t.Run("Marshal", func(t *testing.T) {
+		data, err := json.Marshal(NewMoney(1234, "KZT"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != `{"amount":"12.34","currency":"KZT"}` {
+			t.Errorf("unexpected JSON %s", data)
+		}
+	})
+
+	t.Run("UnmarshalForms", func(t *testing.T) {
+		cases := map[string]Money{
+			`{"amount":"12.34","currency":"USD"}`: NewMoney(1234, "USD"),
+			`"12.34"`:                             NewMoney(1234, DefaultCurrency),
+			`12.34`:                               NewMoney(1234, DefaultCurrency),
+			`100`:                                 NewMoney(10000, DefaultCurrency),
+		}
+		for in, want := range cases {
+			var m Money
+			if err := json.Unmarshal([]byte(in), &m); err != nil {
+				t.Errorf("Unmarshal(%s): %v", in, err)
+				continue
+			}
+			if m != want {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", in, m, want)
+			}
+		}
+	})
+
+	t.Run("RejectsSubMinorPrecision", func(t *testing.T) {
+		var m Money
+		if err := json.Unmarshal([]byte(`12.345`), &m); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("expected ErrInvalidAmount, got %v", err)
+		}
+	})
+}
+
+func TestMoney_Scan(t *testing.T) {
+	m := Money{Currency: "USD"}
+	if err := m.Scan([]byte("99.90")); err != nil {
+		t.Fatal(err)
+	}
+	if m != NewMoney(9990, "USD") {
+		t.Errorf("unexpected scan result %+v", m)
+	}
+
+	var d Money
+	if err := d.Scan("1.00"); err != nil {
+		t.Fatal(err)
+	}
+	if d.Currency != DefaultCurrency {
+		t.Errorf("expected default currency, got %q", d.Currency)
+	}
+
+	v, err := NewMoney(10010, "").Value()
+	if err != nil || v != "100.10" {
+		t.Errorf("unexpected Value() %v, %v", v, err)
+	}
+}
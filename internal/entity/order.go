@@ -10,17 +10,28 @@ type Order struct {
 	ID         int64
 	UserID     int64
 	Items      []OrderItem
-	TotalPrice float64
-	Status     string
+	TotalPrice Money
+	Status     OrderStatus
 	CreatedAt  time.Time
+	// Version увеличивается при каждом изменении заказа и служит для оптимистичной блокировки
+	Version int64
+	// DeletedAt — время мягкого удаления; удалённый заказ скрыт, пока его не восстановят
+	DeletedAt *time.Time
+	// ArchivedAt — время переноса в архив; архивный заказ доступен только по ID
+	ArchivedAt *time.Time
 }
 
 type OrderItem struct {
-	ID        int64   `json:"id"`
-	ProductID int64   `json:"product_id"`
-	Name      string  `json:"name"`
-	Quantity  int64   `json:"quantity"`
-	Price     float64 `json:"price"`
+	ID        int64  `json:"id"`
+	ProductID int64  `json:"product_id"`
+	Name      string `json:"name"`
+	Quantity  int64  `json:"quantity"`
+	Price     Money  `json:"price"`
+}
+
+// LineTotal возвращает сумму строки заказа: цена за единицу × количество
+func (i OrderItem) LineTotal() Money {
+	return i.Price.Mul(i.Quantity)
 }
 
 type PaymentResponse struct {
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"order_service/internal/domainerr"
+	"time"
+)
+
+// ErrInvalidCursor — курсор страницы повреждён или выдан не этим сервисом
+var ErrInvalidCursor = domainerr.New(domainerr.InvalidArgument, "invalid cursor")
+
+// SortDirection — направление сортировки заказов по времени создания
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// OrderCursor — позиция последнего заказа страницы. Заказы упорядочены
+// по (created_at, id), поэтому следующая страница начинается строго после неё.
+type OrderCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        int64     `json:"i"`
+}
+
+// CursorOf возвращает позицию заказа в выдаче
+func CursorOf(order Order) OrderCursor {
+	return OrderCursor{CreatedAt: order.CreatedAt, ID: order.ID}
+}
+
+// Encode возвращает непрозрачное для клиента представление курсора
+func (c OrderCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeOrderCursor разбирает курсор, полученный из Encode
+func DecodeOrderCursor(s string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c OrderCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID <= 0 || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// OrderQuery — параметры выборки заказов пользователя
+type OrderQuery struct {
+	UserID   int64         // 0 — заказы всех пользователей
+	Statuses []OrderStatus // пусто — любой статус
+	// CreatedFrom включительно, CreatedTo не включительно; нулевое значение — без границы
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Sort        SortDirection
+	Limit       int
+	After       *OrderCursor // nil — с начала выдачи
+	// IncludeDeleted добавляет в выдачу мягко удалённые заказы
+	IncludeDeleted bool
+}
+
+// Matches сообщает, подходит ли заказ под фильтры запроса без учёта курсора и лимита
+func (q OrderQuery) Matches(order Order) bool {
+	if q.UserID != 0 && order.UserID != q.UserID {
+		return false
+	}
+	if !q.IncludeDeleted && order.DeletedAt != nil {
+		return false
+	}
+	if !q.CreatedFrom.IsZero() && order.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !order.CreatedAt.Before(q.CreatedTo) {
+		return false
+	}
+	if len(q.Statuses) == 0 {
+		return true
+	}
+	for _, status := range q.Statuses {
+		if order.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Less сообщает, идёт ли a раньше b в порядке выдачи запроса
+func (q OrderQuery) Less(a, b OrderCursor) bool {
+	if q.Sort == SortDesc {
+		a, b = b, a
+	}
+	return a.CreatedAt.Before(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.ID < b.ID)
+}
+
+// OrderPage — страница заказов и курсор следующей страницы
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
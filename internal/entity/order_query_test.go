@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderCursor_Encode(t *testing.T) {
+	cursor := OrderCursor{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC), ID: 42}
+
+	decoded, err := DecodeOrderCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if decoded.ID != cursor.ID || !decoded.CreatedAt.Equal(cursor.CreatedAt) {
+		t.Errorf("expected %+v, got %+v", cursor, decoded)
+	}
+
+	for _, in := range []string{"", "!!!", "bnVsbA", "e30"} {
+		if _, err := DecodeOrderCursor(in); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeOrderCursor(%q): expected ErrInvalidCursor, got %v", in, err)
+		}
+	}
+}
+
+func TestOrderQuery_Less(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := OrderCursor{CreatedAt: at, ID: 1}
+	second := OrderCursor{CreatedAt: at, ID: 2}
+	third := OrderCursor{CreatedAt: at.Add(time.Second), ID: 0}
+
+	asc := OrderQuery{Sort: SortAsc}
+	if !asc.Less(first, second) || !asc.Less(second, third) || asc.Less(second, first) || asc.Less(first, first) {
+		t.Error("unexpected ascending order")
+	}
+	desc := OrderQuery{Sort: SortDesc}
+	if !desc.Less(second, first) || !desc.Less(third, second) || desc.Less(first, second) || desc.Less(first, first) {
+		t.Error("unexpected descending order")
+	}
+}
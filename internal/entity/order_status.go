@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"fmt"
+	"order_service/internal/domainerr"
+)
+
+// OrderStatus — статус заказа
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCanceled  OrderStatus = "canceled"
+	OrderStatusExpired   OrderStatus = "expired"
+	OrderStatusRefunded  OrderStatus = "refunded"
+	OrderStatusFailed    OrderStatus = "failed" // оплата не прошла
+)
+
+// ErrInvalidTransition возвращается при попытке недопустимой смены статуса
+var ErrInvalidTransition = domainerr.New(domainerr.InvalidTransition, "invalid order status transition")
+
+// ErrUnknownStatus возвращается при разборе неизвестного статуса
+var ErrUnknownStatus = domainerr.New(domainerr.InvalidArgument, "unknown order status")
+
+// TransitionError описывает конкретный недопустимый переход.
+// errors.Is(err, ErrInvalidTransition) для него возвращает true.
+type TransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s", ErrInvalidTransition, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// orderTransitions — граф допустимых переходов между статусами.
+// Статусы без исходящих переходов являются конечными.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCanceled, OrderStatusExpired, OrderStatusFailed},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCanceled, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCanceled:  {},
+	OrderStatusExpired:   {},
+	OrderStatusRefunded:  {},
+	OrderStatusFailed:    {},
+}
+
+// ArchivableStatuses — статусы завершённых заказов, которые можно переносить в архив.
+// Оплаченный и отгруженный заказы ещё ждут событий доставки, а архивный заказ
+// статус уже не меняет.
+var ArchivableStatuses = []OrderStatus{
+	OrderStatusDelivered,
+	OrderStatusCanceled,
+	OrderStatusExpired,
+	OrderStatusRefunded,
+	OrderStatusFailed,
+}
+
+// ParseOrderStatus разбирает строку в статус заказа.
+// Устаревшее написание "cancelled" приводится к OrderStatusCanceled.
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	if s == "cancelled" {
+		return OrderStatusCanceled, nil
+	}
+	status := OrderStatus(s)
+	if !status.Valid() {
+		return "", fmt.Errorf("%w: %s", ErrUnknownStatus, s)
+	}
+	return status, nil
+}
+
+// Valid сообщает, известен ли статус
+func (s OrderStatus) Valid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo сообщает, допустим ли переход из s в next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo проверяет переход и возвращает *TransitionError, если он недопустим
+func (s OrderStatus) TransitionTo(next OrderStatus) error {
+	if !s.CanTransitionTo(next) {
+		return &TransitionError{From: s, To: next}
+	}
+	return nil
+}
+
+// Archivable сообщает, можно ли перенести заказ в статусе s в архив
+func (s OrderStatus) Archivable() bool {
+	for _, status := range ArchivableStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrderStatus_TransitionTo(t *testing.T) {
+	allowed := []struct{ from, to OrderStatus }{
+		{OrderStatusPending, OrderStatusPaid},
+		{OrderStatusPending, OrderStatusCanceled},
+		{OrderStatusPending, OrderStatusExpired},
+		{OrderStatusPending, OrderStatusFailed},
+		{OrderStatusPaid, OrderStatusShipped},
+		{OrderStatusPaid, OrderStatusCanceled},
+		{OrderStatusPaid, OrderStatusRefunded},
+		{OrderStatusShipped, OrderStatusDelivered},
+		{OrderStatusDelivered, OrderStatusRefunded},
+	}
+	for _, tc := range allowed {
+		if err := tc.from.TransitionTo(tc.to); err != nil {
+			t.Errorf("expected %s -> %s to be allowed, got %v", tc.from, tc.to, err)
+		}
+	}
+
+	forbidden := []struct{ from, to OrderStatus }{
+		{OrderStatusDelivered, OrderStatusPaid},
+		{OrderStatusPending, OrderStatusShipped},
+		{OrderStatusPending, OrderStatusPending},
+		{OrderStatusCanceled, OrderStatusPaid},
+		{OrderStatusExpired, OrderStatusPaid},
+		{OrderStatusRefunded, OrderStatusDelivered},
+		{OrderStatusShipped, OrderStatusCanceled},
+		{OrderStatusFailed, OrderStatusPaid},
+		{OrderStatus("unknown"), OrderStatusPaid},
+	}
+	for _, tc := range forbidden {
+		err := tc.from.TransitionTo(tc.to)
+		if !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("expected %s -> %s to fail with ErrInvalidTransition, got %v", tc.from, tc.to, err)
+		}
+		var transitionErr *TransitionError
+		if !errors.As(err, &transitionErr) || transitionErr.From != tc.from || transitionErr.To != tc.to {
+			t.Errorf("expected *TransitionError{%s, %s}, got %v", tc.from, tc.to, err)
+		}
+	}
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	t.Run("Known", func(t *testing.T) {
+		status, err := ParseOrderStatus("paid")
+		if err != nil || status != OrderStatusPaid {
+			t.Errorf("expected paid, got %v, %v", status, err)
+		}
+	})
+
+	t.Run("LegacySpelling", func(t *testing.T) {
+		status, err := ParseOrderStatus("cancelled")
+		if err != nil || status != OrderStatusCanceled {
+			t.Errorf("expected canceled, got %v, %v", status, err)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := ParseOrderStatus("invalid")
+		if !errors.Is(err, ErrUnknownStatus) {
+			t.Errorf("expected ErrUnknownStatus, got %v", err)
+		}
+	})
+}
+
+func TestOrderStatus_Archivable(t *testing.T) {
+	for _, status := range []OrderStatus{OrderStatusPending, OrderStatusPaid, OrderStatusShipped} {
+		if status.Archivable() {
+			t.Errorf("expected %s order to stay live", status)
+		}
+	}
+	for _, status := range []OrderStatus{OrderStatusDelivered, OrderStatusCanceled, OrderStatusExpired, OrderStatusRefunded, OrderStatusFailed} {
+		if !status.Archivable() {
+			t.Errorf("expected %s order to be archivable", status)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OrderEventType — тип доменного события заказа
+type OrderEventType string
+
+const (
+	OrderEventCreated       OrderEventType = "order.created"
+	OrderEventStatusChanged OrderEventType = "order.status_changed"
+	OrderEventCanceled      OrderEventType = "order.canceled"
+	OrderEventExpired       OrderEventType = "order.expired"
+	OrderEventDeleted       OrderEventType = "order.deleted"
+	OrderEventRestored      OrderEventType = "order.restored"
+)
+
+// OrderEvent — тело события, которое публикуется в Kafka
+type OrderEvent struct {
+	Type       OrderEventType `json:"type"`
+	OrderID    int64          `json:"order_id"`
+	UserID     int64          `json:"user_id"`
+	OldStatus  OrderStatus    `json:"old_status,omitempty"`
+	Status     OrderStatus    `json:"status"`
+	TotalPrice *Money         `json:"total_price,omitempty"`
+	Actor      string         `json:"actor,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// OrderCreatedEvent возвращает событие создания заказа
+func OrderCreatedEvent(order *Order) OrderEvent {
+	total := order.TotalPrice
+	return OrderEvent{
+		Type:       OrderEventCreated,
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Status:     order.Status,
+		TotalPrice: &total,
+		OccurredAt: order.CreatedAt,
+	}
+}
+
+// StatusChangedEvent возвращает событие смены статуса.
+// Отмена и истечение публикуются отдельными типами, остальные переходы — как status_changed.
+func StatusChangedEvent(userID int64, change *StatusChange) OrderEvent {
+	eventType := OrderEventStatusChanged
+	switch change.NewStatus {
+	case OrderStatusCanceled:
+		eventType = OrderEventCanceled
+	case OrderStatusExpired:
+		eventType = OrderEventExpired
+	}
+	return OrderEvent{
+		Type:       eventType,
+		OrderID:    change.OrderID,
+		UserID:     userID,
+		OldStatus:  change.OldStatus,
+		Status:     change.NewStatus,
+		Actor:      change.Actor,
+		Reason:     change.Reason,
+		OccurredAt: change.CreatedAt,
+	}
+}
+
+// OrderDeletedEvent возвращает событие мягкого удаления заказа. Статус при удалении
+// не меняется, но резервы заказа снимаются, и из выборок он пропадает.
+func OrderDeletedEvent(userID, orderID int64, status OrderStatus, at time.Time) OrderEvent {
+	return OrderEvent{Type: OrderEventDeleted, OrderID: orderID, UserID: userID, Status: status, OccurredAt: at}
+}
+
+// OrderRestoredEvent возвращает событие восстановления удалённого заказа
+func OrderRestoredEvent(userID, orderID int64, status OrderStatus, at time.Time) OrderEvent {
+	return OrderEvent{Type: OrderEventRestored, OrderID: orderID, UserID: userID, Status: status, OccurredAt: at}
+}
+
+// OutboxEvent — запись outbox: событие, сохранённое в одной транзакции с изменением заказа
+// и ещё не обязательно опубликованное
+type OutboxEvent struct {
+	ID        int64
+	OrderID   int64
+	Type      OrderEventType
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+// NewOutboxEvent сериализует событие для записи в outbox
+func NewOutboxEvent(event OrderEvent) (*OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{OrderID: event.OrderID, Type: event.Type, Payload: payload}, nil
+}
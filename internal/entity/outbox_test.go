@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStatusChangedEvent(t *testing.T) {
+	tests := []struct {
+		status   OrderStatus
+		expected OrderEventType
+	}{
+		{OrderStatusPaid, OrderEventStatusChanged},
+		{OrderStatusCanceled, OrderEventCanceled},
+		{OrderStatusExpired, OrderEventExpired},
+		{OrderStatusFailed, OrderEventStatusChanged},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			// Выполнение
+			event := StatusChangedEvent(3, &StatusChange{OrderID: 1, OldStatus: OrderStatusPending, NewStatus: tt.status})
+
+			// Проверка
+			if event.Type != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, event.Type)
+			}
+			if event.UserID != 3 || event.OrderID != 1 {
+				t.Errorf("unexpected event %+v", event)
+			}
+		})
+	}
+}
+
+func TestNewOutboxEvent(t *testing.T) {
+	// Подготовка
+	order := &Order{
+		ID:         5,
+		UserID:     3,
+		TotalPrice: NewMoney(1250, "KZT"),
+		Status:     OrderStatusPending,
+		CreatedAt:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	// Выполнение
+	record, err := NewOutboxEvent(OrderCreatedEvent(order))
+
+	// Проверка
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if record.OrderID != 5 || record.Type != OrderEventCreated {
+		t.Errorf("unexpected record %+v", record)
+	}
+	expected := `{"type":"order.created","order_id":5,"user_id":3,"status":"pending",` +
+		`"total_price":{"amount":"12.50","currency":"KZT"},"occurred_at":"2025-01-02T03:04:05Z"}`
+	if !json.Valid(record.Payload) || string(record.Payload) != expected {
+		t.Errorf("expected payload %s, got %s", expected, record.Payload)
+	}
+}
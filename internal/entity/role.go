@@ -0,0 +1,10 @@
+package entity
+
+// Роли пользователей из claim role в JWT. Пользователь без особой роли
+// работает только со своими заказами.
+const (
+	// RoleAdmin видит и меняет заказы всех пользователей
+	RoleAdmin = "admin"
+	// RoleSupport видит заказы всех пользователей, но не меняет их
+	RoleSupport = "support"
+)
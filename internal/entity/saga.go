@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// SagaStatus — состояние саги создания заказа
+type SagaStatus string
+
+const (
+	SagaStatusRunning      SagaStatus = "running"      // шаги выполняются
+	SagaStatusCompleted    SagaStatus = "completed"    // все шаги выполнены
+	SagaStatusCompensating SagaStatus = "compensating" // выполняются компенсации
+	SagaStatusCompensated  SagaStatus = "compensated"  // изменения откачены
+)
+
+// SagaStep — последний успешно выполненный шаг саги
+type SagaStep string
+
+const (
+	SagaStepStarted            SagaStep = "started"
+	SagaStepValidateStock      SagaStep = "validate_stock"
+	SagaStepPersistOrder       SagaStep = "persist_order"
+	SagaStepRequestPaymentLink SagaStep = "request_payment_link"
+)
+
+// OrderSaga — сохраняемое состояние саги создания заказа.
+// По нему перезапущенный процесс решает, довести сагу до конца или откатить.
+type OrderSaga struct {
+	ID         int64
+	UserID     int64
+	OrderID    int64 // 0, пока заказ не сохранён
+	Step       SagaStep
+	Status     SagaStatus
+	PaymentURL string
+	Error      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
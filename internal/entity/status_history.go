@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+)
+
+// StatusChange — запись истории смены статуса заказа
+type StatusChange struct {
+	ID        int64       `json:"id"`
+	OrderID   int64       `json:"order_id"`
+	OldStatus OrderStatus `json:"old_status"`
+	NewStatus OrderStatus `json:"new_status"`
+	Actor     string      `json:"actor"`
+	Reason    string      `json:"reason,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	// ExpectedVersion — версия заказа, которую видел инициатор; 0 — без проверки
+	ExpectedVersion int64 `json:"-"`
+}
+
+// UserActor возвращает инициатора изменения для пользователя
+func UserActor(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
+// StaffActor возвращает инициатора изменения для сотрудника с ролью role (admin, support и т.д.),
+// например "admin:101": в истории видно, кто именно сменил статус
+func StaffActor(role string, userID int64) string {
+	return role + ":" + strconv.FormatInt(userID, 10)
+}
+
+// KafkaActor возвращает инициатора изменения для сообщений из топика Kafka
+func KafkaActor(topic string) string {
+	return "kafka:" + topic
+}
+
+// SystemActor возвращает инициатора изменения для фоновых процессов сервиса
+func SystemActor(process string) string {
+	return "system:" + process
+}
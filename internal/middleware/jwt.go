@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"order_service/internal/authz"
+	"order_service/internal/delivery/problem"
 	"os"
 	"strings"
 
@@ -32,7 +34,7 @@ func JWTMiddleware(next http.Handler) http.Handler {
 			// 2. Если нет — пробуем из cookie
 			cookie, err := r.Cookie("token")
 			if err != nil {
-				http.Error(w, "Missing token", http.StatusUnauthorized)
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "missing token")
 				return
 			}
 			tokenStr = cookie.Value
@@ -46,13 +48,13 @@ func JWTMiddleware(next http.Handler) http.Handler {
 		if err != nil || !token.Valid {
 			fmt.Println("❌ JWT error:", err)
 			fmt.Println("❌ Token string:", tokenStr)
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "invalid token")
 			return
 		}
 
 		userIDFloat, ok := claims["user_id"].(float64)
 		if !ok {
-			http.Error(w, "Invalid user_id", http.StatusUnauthorized)
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "invalid user_id")
 			return
 		}
 		userID := int64(userIDFloat)
@@ -61,6 +63,7 @@ func JWTMiddleware(next http.Handler) http.Handler {
 
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
 		ctx = context.WithValue(ctx, RoleKey, role)
+		ctx = authz.WithPrincipal(ctx, authz.Principal{UserID: userID, Role: role})
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
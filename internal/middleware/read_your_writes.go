@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"net/http"
+
+	"order_service/internal/repository"
+)
+
+// ReadYourWrites делает HTTP-запрос границей чтения своих записей: после того
+// как запрос обратился к основной базе, его чтения не уходят на реплики
+func ReadYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(repository.WithReadYourWrites(r.Context())))
+	})
+}
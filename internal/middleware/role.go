@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"order_service/internal/delivery/problem"
+	"slices"
+)
+
+// RequireRole пропускает запрос, только если роль из JWT входит в roles,
+// иначе отвечает 403. Ставится после JWTMiddleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := GetRoleFromContext(r.Context())
+			if !ok {
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "authentication required")
+				return
+			}
+			if !slices.Contains(roles, role) {
+				problem.Write(w, r, http.StatusForbidden, problem.CodeForbidden, "role is not allowed to access this resource")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,344 @@
+// Package migrations хранит версионированные SQL-миграции схемы и применяет их.
+//
+// Миграции лежат в каталоге диалекта (postgres/..., sqlite/...) и встраиваются в бинарник.
+// Файлы именуются NNNN_название.up.sql и NNNN_название.down.sql.
+// Применённые версии записываются в таблицу migrations.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// ErrSchemaOutdated возвращается, если в базе применены не все миграции
+var ErrSchemaOutdated = errors.New("database schema is outdated")
+
+// ErrUnknownVersion возвращается, если в базе применена миграция, которой нет в бинарнике
+var ErrUnknownVersion = errors.New("database schema is newer than the binary")
+
+// Migration — одна версия схемы
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status — состояние миграции в конкретной базе
+type Status struct {
+	Migration
+	AppliedAt *time.Time // nil, если миграция не применена
+}
+
+// Load читает встроенные миграции диалекта в порядке версий
+func Load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %w", dialect, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		body, err := files.ReadFile(path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %04d has conflicting names %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be sequential: expected %04d, got %04d", i+1, m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// parseFileName разбирает имя вида 0001_create_orders.up.sql
+func parseFileName(fileName string) (version int, name, direction string, err error) {
+	base, ok := strings.CutSuffix(fileName, ".sql")
+	if !ok {
+		return 0, "", "", fmt.Errorf("unexpected migration file %q", fileName)
+	}
+	base, direction = strings.TrimSuffix(base, path.Ext(base)), strings.TrimPrefix(path.Ext(base), ".")
+	if direction != "up" && direction != "down" {
+		return 0, "", "", fmt.Errorf("migration file %q must end with .up.sql or .down.sql", fileName)
+	}
+	rawVersion, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, "", "", fmt.Errorf("migration file %q must be named NNNN_name", fileName)
+	}
+	version, err = strconv.Atoi(rawVersion)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration file %q has invalid version", fileName)
+	}
+	return version, name, direction, nil
+}
+
+// Migrator применяет миграции к базе
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// Классы advisory-блокировок Postgres. Блокировки берутся в форме из двух int4
+// (класс, объект), поэтому блокировки разного назначения не пересекаются,
+// даже если их объекты совпадают.
+const (
+	// AdvisoryClassMigrations — запуск миграций: Up и Down исключают
+	// одновременный запуск несколькими экземплярами сервиса
+	AdvisoryClassMigrations int32 = 1
+	// AdvisoryClassStock — резервы товара; объект — ID товара
+	AdvisoryClassStock int32 = 2
+)
+
+// lockObject — объект блокировки миграций в классе AdvisoryClassMigrations
+const lockObject int32 = 0
+
+// querier — то общее, что нужно миграциям от *sql.DB и *sql.Conn
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// New создаёт Migrator для встроенных миграций диалекта
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Latest возвращает последнюю известную версию схемы
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, q querier) error {
+	_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// tableExists сообщает, есть ли в базе таблица migrations
+func (m *Migrator) tableExists(ctx context.Context, q querier) (bool, error) {
+	query := "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'migrations'"
+	if m.dialect == "postgres" {
+		query = "SELECT to_regclass('migrations') IS NOT NULL"
+	}
+	var exists bool
+	err := q.QueryRowContext(ctx, query).Scan(&exists)
+	return exists, err
+}
+
+// applied возвращает время применения каждой версии. Запрос только читает:
+// в базе без таблицы migrations не применена ни одна версия.
+func (m *Migrator) applied(ctx context.Context, q querier) (map[int]time.Time, error) {
+	exists, err := m.tableExists(ctx, q)
+	if err != nil || !exists {
+		return map[int]time.Time{}, err
+	}
+	rows, err := q.QueryContext(ctx, "SELECT version, applied_at FROM migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// Version возвращает текущую версию схемы: число применённых подряд миграций
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for version < len(m.migrations) {
+		if _, ok := applied[m.migrations[version].Version]; !ok {
+			break
+		}
+		version++
+	}
+	for v := range applied {
+		if v > m.Latest() {
+			return version, fmt.Errorf("%w: version %d is applied, binary knows up to %d", ErrUnknownVersion, v, m.Latest())
+		}
+	}
+	return version, nil
+}
+
+// Status возвращает состояние всех миграций
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up применяет все неприменённые миграции и возвращает их версии
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	var done []int
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err := m.inTx(ctx, conn, migration.Up,
+				"INSERT INTO migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down откатывает steps последних применённых миграций и возвращает их версии
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	var done []int
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			err := m.inTx(ctx, conn, migration.Down, "DELETE FROM migrations WHERE version = $1", migration.Version)
+			if err != nil {
+				return fmt.Errorf("rollback %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// locked выполняет fn на одном соединении с созданной таблицей migrations.
+// В Postgres соединение держит pg_advisory_lock: другой экземпляр ждёт, пока
+// fn не завершится, и затем видит уже применённые версии.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect == "postgres" {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1, $2)", AdvisoryClassMigrations, lockObject); err != nil {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+		// Блокировку нужно снять и после отмены ctx, иначе она останется на соединении в пуле
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1, $2)", AdvisoryClassMigrations, lockObject)
+	}
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// Verify проверяет, что в базе применены все миграции бинарника. Проверка только
+// читает базу, поэтому её можно выполнять при каждом запуске сервиса.
+func (m *Migrator) Verify(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("%w: version %d, expected %d (run \"migrate up\")", ErrSchemaOutdated, version, m.Latest())
+	}
+	return nil
+}
+
+// inTx выполняет миграцию и запись в migrations в одной транзакции
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,240 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLoad(t *testing.T) {
+	// Выполнение
+	migrations, err := Load("postgres")
+
+	// Проверка
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_orders" {
+		t.Errorf("unexpected first migration %d_%s", migrations[0].Version, migrations[0].Name)
+	}
+	for _, m := range migrations {
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("migration %04d must have up and down scripts", m.Version)
+		}
+	}
+
+	if _, err := Load("oracle"); err == nil {
+		t.Error("expected error for unknown dialect")
+	}
+}
+
+func TestParseFileName(t *testing.T) {
+	tests := []struct {
+		fileName  string
+		version   int
+		name      string
+		direction string
+		wantErr   bool
+	}{
+		{"0001_create_orders.up.sql", 1, "create_orders", "up", false},
+		{"0012_add_index.down.sql", 12, "add_index", "down", false},
+		{"0001_create_orders.sql", 0, "", "", true},
+		{"create_orders.up.sql", 0, "", "", true},
+		{"0001_create_orders.up.txt", 0, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			// Выполнение
+			version, name, direction, err := parseFileName(tt.fileName)
+
+			// Проверка
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if version != tt.version || name != tt.name || direction != tt.direction {
+				t.Errorf("expected %d %s %s, got %d %s %s", tt.version, tt.name, tt.direction, version, name, direction)
+			}
+		})
+	}
+}
+
+func newMigrator(t *testing.T, dialect string) (*Migrator, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	return &Migrator{db: db, dialect: dialect, migrations: []Migration{
+		{Version: 1, Name: "create_orders", Up: "CREATE TABLE orders", Down: "DROP TABLE orders"},
+		{Version: 2, Name: "add_currency", Up: "ALTER TABLE orders ADD", Down: "ALTER TABLE orders DROP"},
+	}}, mock
+}
+
+// expectApplied ожидает чтение применённых версий из существующей таблицы migrations
+func expectApplied(mock sqlmock.Sqlmock, versions ...int) {
+	mock.ExpectQuery("FROM sqlite_master").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, v := range versions {
+		rows.AddRow(v, time.Now())
+	}
+	mock.ExpectQuery("SELECT version, applied_at FROM migrations").WillReturnRows(rows)
+}
+
+func TestMigrator_Up(t *testing.T) {
+	t.Run("AppliesPending", func(t *testing.T) {
+		// Подготовка
+		migrator, mock := newMigrator(t, "sqlite")
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectApplied(mock, 1)
+		mock.ExpectBegin()
+		mock.ExpectExec("ALTER TABLE orders ADD").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO migrations").WithArgs(2, "add_currency").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Выполнение
+		applied, err := migrator.Up(t.Context())
+
+		// Проверка
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(applied) != 1 || applied[0] != 2 {
+			t.Errorf("expected [2], got %v", applied)
+		}
+	})
+
+	t.Run("RollsBackFailedMigration", func(t *testing.T) {
+		// Подготовка
+		migrator, mock := newMigrator(t, "sqlite")
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		expectApplied(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TABLE orders").WillReturnError(errors.New("syntax error"))
+		mock.ExpectRollback()
+
+		// Выполнение
+		applied, err := migrator.Up(t.Context())
+
+		// Проверка
+		if err == nil || err.Error() != "migration 0001_create_orders: syntax error" {
+			t.Errorf("expected migration error, got %v", err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("expected nothing applied, got %v", applied)
+		}
+	})
+
+	t.Run("PostgresAdvisoryLock", func(t *testing.T) {
+		// Подготовка: второй экземпляр ждёт блокировку и видит уже применённые версии
+		migrator, mock := newMigrator(t, "postgres")
+		mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(AdvisoryClassMigrations, lockObject).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("SELECT version, applied_at FROM migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()).AddRow(2, time.Now()))
+		mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(AdvisoryClassMigrations, lockObject).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Выполнение
+		applied, err := migrator.Up(t.Context())
+
+		// Проверка
+		if err != nil || len(applied) != 0 {
+			t.Errorf("expected nothing to apply, got %v, %v", applied, err)
+		}
+	})
+}
+
+func TestMigrator_Down(t *testing.T) {
+	// Подготовка
+	migrator, mock := newMigrator(t, "sqlite")
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectApplied(mock, 1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE orders DROP").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM migrations").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Выполнение
+	rolledBack, err := migrator.Down(t.Context(), 1)
+
+	// Проверка
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rolledBack) != 1 || rolledBack[0] != 2 {
+		t.Errorf("expected [2], got %v", rolledBack)
+	}
+}
+
+func TestMigrator_Verify(t *testing.T) {
+	tests := []struct {
+		name     string
+		applied  []int
+		expected error
+	}{
+		{"UpToDate", []int{1, 2}, nil},
+		{"Outdated", []int{1}, ErrSchemaOutdated},
+		{"Empty", nil, ErrSchemaOutdated},
+		{"NewerThanBinary", []int{1, 2, 3}, ErrUnknownVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			migrator, mock := newMigrator(t, "sqlite")
+			expectApplied(mock, tt.applied...)
+
+			// Выполнение
+			err := migrator.Verify(t.Context())
+
+			// Проверка
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	t.Run("MissingTable", func(t *testing.T) {
+		// Подготовка: проверка только читает и не создаёт таблицу migrations
+		migrator, mock := newMigrator(t, "postgres")
+		mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		// Выполнение
+		version, err := migrator.Version(t.Context())
+
+		// Проверка
+		if err != nil || version != 0 {
+			t.Errorf("expected version 0, got %d, %v", version, err)
+		}
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	// Подготовка
+	migrator, mock := newMigrator(t, "sqlite")
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("FROM sqlite_master").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT version, applied_at FROM migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, at))
+
+	// Выполнение
+	statuses, err := migrator.Status(t.Context())
+
+	// Проверка
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || !statuses[0].AppliedAt.Equal(at) || statuses[1].AppliedAt != nil {
+		t.Errorf("unexpected statuses %+v", statuses)
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS reserved_stock (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id INT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reserved_stock_product_id ON reserved_stock (product_id);
+CREATE INDEX IF NOT EXISTS idx_reserved_stock_created_at ON reserved_stock (created_at);
@@ -0,0 +1,8 @@
+-- Локальная копия остатков товаров, по которой считается GetAvailableStock.
+-- Источник истины по товарам — Product Service.
+CREATE TABLE IF NOT EXISTS products (
+    id INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS order_sagas (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    order_id INT,
+    step VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    payment_url TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_sagas_unfinished ON order_sagas (updated_at) WHERE status IN ('running', 'compensating');
@@ -0,0 +1,40 @@
+-- Архивные таблицы повторяют основные, но без внешних ключей: заказ переносится
+-- сюда целиком и удаляется из orders
+CREATE TABLE IF NOT EXISTS orders_archive (
+    id INT PRIMARY KEY,
+    user_id INT NOT NULL,
+    total_price DECIMAL(10,2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    version BIGINT NOT NULL,
+    deleted_at TIMESTAMP,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS order_items_archive (
+    id INT PRIMARY KEY,
+    order_id INT NOT NULL,
+    product_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL,
+    price DECIMAL(10,2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_archive_order_id ON order_items_archive (order_id);
+
+CREATE TABLE IF NOT EXISTS order_status_history_archive (
+    id INT PRIMARY KEY,
+    order_id INT NOT NULL,
+    old_status VARCHAR(50),
+    new_status VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_archive_order_id ON order_status_history_archive (order_id, created_at);
+
+-- Поиск заказов для переноса в архив
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders (created_at);
@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN currency TEXT NOT NULL DEFAULT 'KZT';
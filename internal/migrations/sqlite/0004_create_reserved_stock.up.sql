@@ -0,0 +1,10 @@
+CREATE TABLE reserved_stock (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_reserved_stock_product_id ON reserved_stock (product_id);
+CREATE INDEX idx_reserved_stock_created_at ON reserved_stock (created_at);
@@ -0,0 +1,8 @@
+-- Локальная копия остатков товаров, по которой считается GetAvailableStock.
+-- Источник истины по товарам — Product Service.
+CREATE TABLE products (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
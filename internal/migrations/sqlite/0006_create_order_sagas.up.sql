@@ -0,0 +1,13 @@
+CREATE TABLE order_sagas (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    order_id INTEGER,
+    step TEXT NOT NULL,
+    status TEXT NOT NULL,
+    payment_url TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_order_sagas_unfinished ON order_sagas (updated_at) WHERE status IN ('running', 'compensating');
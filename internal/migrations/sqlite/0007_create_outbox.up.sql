@@ -0,0 +1,10 @@
+CREATE TABLE outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_unsent ON outbox (id) WHERE sent_at IS NULL;
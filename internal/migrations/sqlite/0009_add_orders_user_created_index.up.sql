@@ -0,0 +1 @@
+CREATE INDEX idx_orders_user_id_created_at ON orders (user_id, created_at, id);
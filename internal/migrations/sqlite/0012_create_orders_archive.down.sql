@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_created_at;
+DROP TABLE IF EXISTS order_status_history_archive;
+DROP TABLE IF EXISTS order_items_archive;
+DROP TABLE IF EXISTS orders_archive;
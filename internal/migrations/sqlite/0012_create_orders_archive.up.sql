@@ -0,0 +1,40 @@
+-- Архивные таблицы повторяют основные, но без внешних ключей: заказ переносится
+-- сюда целиком и удаляется из orders
+CREATE TABLE orders_archive (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    total_price TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    version INTEGER NOT NULL,
+    deleted_at TIMESTAMP,
+    archived_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE order_items_archive (
+    id INTEGER PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    quantity INTEGER NOT NULL,
+    price TEXT NOT NULL
+);
+
+CREATE INDEX idx_order_items_archive_order_id ON order_items_archive (order_id);
+
+CREATE TABLE order_status_history_archive (
+    id INTEGER PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    old_status TEXT,
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_order_status_history_archive_order_id ON order_status_history_archive (order_id, created_at);
+
+-- Поиск заказов для переноса в архив
+CREATE INDEX idx_orders_created_at ON orders (created_at);
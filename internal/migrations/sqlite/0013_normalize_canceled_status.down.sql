@@ -0,0 +1,2 @@
+-- Исправление данных необратимо: какие записи хранили "cancelled", неизвестно
+SELECT 1;
@@ -0,0 +1,8 @@
+-- Старые записи хранят устаревшее написание "cancelled"; приводим их к "canceled"
+UPDATE orders SET status = 'canceled' WHERE status = 'cancelled';
+UPDATE orders_archive SET status = 'canceled' WHERE status = 'cancelled';
+
+UPDATE order_status_history SET old_status = 'canceled' WHERE old_status = 'cancelled';
+UPDATE order_status_history SET new_status = 'canceled' WHERE new_status = 'cancelled';
+UPDATE order_status_history_archive SET old_status = 'canceled' WHERE old_status = 'cancelled';
+UPDATE order_status_history_archive SET new_status = 'canceled' WHERE new_status = 'cancelled';
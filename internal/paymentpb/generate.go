@@ -0,0 +1,4 @@
+// Package paymentpb содержит gRPC-клиент сервиса оплаты, сгенерированный из proto/payment.proto.
+package paymentpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go-grpc_out=../.. proto/payment.proto
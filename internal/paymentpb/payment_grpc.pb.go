@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PaymentService_GeneratePaymentLink_FullMethodName = "/paymentpb.PaymentService/GeneratePaymentLink"
+	PaymentService_GeneratePaymentLink_FullMethodName   = "/paymentpb.PaymentService/GeneratePaymentLink"
+	PaymentService_InvalidatePaymentLink_FullMethodName = "/paymentpb.PaymentService/InvalidatePaymentLink"
 )
 
 // PaymentServiceClient is the client API for PaymentService service.
@@ -27,6 +28,8 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PaymentServiceClient interface {
 	GeneratePaymentLink(ctx context.Context, in *PaymentRequest, opts ...grpc.CallOption) (*PaymentResponse, error)
+	// Аннулирует ссылку на оплату, например для истёкшего заказа
+	InvalidatePaymentLink(ctx context.Context, in *InvalidatePaymentLinkRequest, opts ...grpc.CallOption) (*InvalidatePaymentLinkResponse, error)
 }
 
 type paymentServiceClient struct {
@@ -47,11 +50,23 @@ func (c *paymentServiceClient) GeneratePaymentLink(ctx context.Context, in *Paym
 	return out, nil
 }
 
+func (c *paymentServiceClient) InvalidatePaymentLink(ctx context.Context, in *InvalidatePaymentLinkRequest, opts ...grpc.CallOption) (*InvalidatePaymentLinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InvalidatePaymentLinkResponse)
+	err := c.cc.Invoke(ctx, PaymentService_InvalidatePaymentLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentServiceServer is the server API for PaymentService service.
 // All implementations must embed UnimplementedPaymentServiceServer
 // for forward compatibility.
 type PaymentServiceServer interface {
 	GeneratePaymentLink(context.Context, *PaymentRequest) (*PaymentResponse, error)
+	// Аннулирует ссылку на оплату, например для истёкшего заказа
+	InvalidatePaymentLink(context.Context, *InvalidatePaymentLinkRequest) (*InvalidatePaymentLinkResponse, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -65,6 +80,9 @@ type UnimplementedPaymentServiceServer struct{}
 func (UnimplementedPaymentServiceServer) GeneratePaymentLink(context.Context, *PaymentRequest) (*PaymentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GeneratePaymentLink not implemented")
 }
+func (UnimplementedPaymentServiceServer) InvalidatePaymentLink(context.Context, *InvalidatePaymentLinkRequest) (*InvalidatePaymentLinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InvalidatePaymentLink not implemented")
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 func (UnimplementedPaymentServiceServer) testEmbeddedByValue()                        {}
 
@@ -104,6 +122,24 @@ func _PaymentService_GeneratePaymentLink_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_InvalidatePaymentLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvalidatePaymentLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).InvalidatePaymentLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_InvalidatePaymentLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).InvalidatePaymentLink(ctx, req.(*InvalidatePaymentLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +151,10 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GeneratePaymentLink",
 			Handler:    _PaymentService_GeneratePaymentLink_Handler,
 		},
+		{
+			MethodName: "InvalidatePaymentLink",
+			Handler:    _PaymentService_InvalidatePaymentLink_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/payment.proto",
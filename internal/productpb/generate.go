@@ -0,0 +1,4 @@
+// Package productpb содержит gRPC-клиент сервиса продуктов, сгенерированный из proto/product.proto.
+package productpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go-grpc_out=../.. proto/product.proto
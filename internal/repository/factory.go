@@ -1,11 +1,19 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"order_service/internal/migrations"
 	"os"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // DatabaseType определяет доступные базы данных
@@ -14,6 +22,17 @@ type DatabaseType string
 const (
 	Postgres DatabaseType = "postgres"
 	Mongo    DatabaseType = "mongo"
+	Memory   DatabaseType = "memory"
+	SQLite   DatabaseType = "sqlite"
+)
+
+const (
+	// defaultSQLitePath — файл базы, если SQLITE_PATH не задан
+	defaultSQLitePath = "order_service.db"
+	// defaultMongoDatabase — база MongoDB, если MONGO_DB не задан
+	defaultMongoDatabase = "order_service"
+	// defaultReplicaCheckInterval — период проверки реплик, если DB_REPLICA_CHECK_INTERVAL не задан
+	defaultReplicaCheckInterval = 5 * time.Second
 )
 
 
@@ -22,15 +41,154 @@ func NewDatabaseConnection(dbType DatabaseType) (OrderRepository, error) {
 	switch dbType {
 	case Postgres:
 		return NewPostgresRepository()
-	// case Mongo:
-	// 	return NewMongoRepository() // Когда добавишь Mongo
+	case Memory:
+		return NewMemoryOrderRepository(), nil
+	case SQLite:
+		return NewSQLiteRepository()
+	case Mongo:
+		return NewMongoRepository()
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", dbType)
 	}
 }
 
-// NewPostgresRepository создает подключение к Postgres
+// OpenSQL открывает SQL-базу выбранного типа и возвращает её диалект миграций
+func OpenSQL(dbType DatabaseType) (*sql.DB, string, error) {
+	switch dbType {
+	case Postgres:
+		db, err := OpenPostgres()
+		return db, string(Postgres), err
+	case SQLite:
+		db, err := OpenSQLite()
+		return db, string(SQLite), err
+	default:
+		return nil, "", fmt.Errorf("migrations are not supported for database type: %s", dbType)
+	}
+}
+
+// NewPostgresRepository создает подключение к Postgres и проверяет версию схемы
 func NewPostgresRepository() (OrderRepository, error) {
+	db, err := OpenPostgres()
+	if err != nil {
+		return nil, err
+	}
+
+	// SCHEMA_CHECK=off отключает проверку, например во время выкладки миграций
+	if os.Getenv("SCHEMA_CHECK") != "off" {
+		if err := VerifySchema(db, string(Postgres)); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	replicas, err := OpenPostgresReplicas()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if replicas == nil {
+		return NewPostgresOrderRepository(db), nil
+	}
+	return NewPostgresOrderRepository(db, WithReadReplicas(replicas)), nil
+}
+
+// OpenPostgresReplicas открывает реплики из DB_REPLICA_DSNS (строки подключения
+// через запятую) и запускает их проверку раз в DB_REPLICA_CHECK_INTERVAL.
+// Без DB_REPLICA_DSNS возвращает nil. Недоступная при старте реплика
+// не мешает запуску: чтения идут в основную базу, пока она не ответит.
+func OpenPostgresReplicas() (*ReplicaSet, error) {
+	raw := os.Getenv("DB_REPLICA_DSNS")
+	if raw == "" {
+		return nil, nil
+	}
+
+	interval := defaultReplicaCheckInterval
+	if value := os.Getenv("DB_REPLICA_CHECK_INTERVAL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid DB_REPLICA_CHECK_INTERVAL: %q", value)
+		}
+		interval = d
+	}
+
+	var dbs []*sql.DB
+	for _, dsn := range strings.Split(raw, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn == "" {
+			continue
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			for _, opened := range dbs {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to open replica: %v", err)
+		}
+		dbs = append(dbs, db)
+	}
+	return NewReplicaSet(dbs, interval), nil
+}
+
+// NewSQLiteRepository открывает файл SQLite и проверяет версию схемы
+func NewSQLiteRepository() (OrderRepository, error) {
+	db, err := OpenSQLite()
+	if err != nil {
+		return nil, err
+	}
+
+	if os.Getenv("SCHEMA_CHECK") != "off" {
+		if err := VerifySchema(db, string(SQLite)); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return NewSQLiteOrderRepository(db), nil
+}
+
+// NewMongoRepository подключается к MongoDB из MONGO_URI, создаёт индексы
+// и исправляет устаревшие данные.
+// Транзакции требуют replica set, например mongodb://host:27017/?replicaSet=rs0.
+func NewMongoRepository() (OrderRepository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(os.Getenv("MONGO_URI")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongo: %v", err)
+	}
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("failed to ping mongo: %v", err)
+	}
+
+	dbName := os.Getenv("MONGO_DB")
+	if dbName == "" {
+		dbName = defaultMongoDatabase
+	}
+
+	repo := NewMongoOrderRepository(client, dbName)
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	if err := repo.NormalizeLegacyStatuses(ctx); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	return repo, nil
+}
+
+// VerifySchema проверяет, что в базе применены все встроенные миграции диалекта
+func VerifySchema(db *sql.DB, dialect string) error {
+	migrator, err := migrations.New(db, dialect)
+	if err != nil {
+		return err
+	}
+	return migrator.Verify(context.Background())
+}
+
+// OpenPostgres открывает соединение с Postgres по переменным окружения
+func OpenPostgres() (*sql.DB, error) {
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		os.Getenv("USER_ORDER_SERVICE"),
 		os.Getenv("PASSWORD_ORDER_SERVICE"),
@@ -49,5 +207,35 @@ func NewPostgresRepository() (OrderRepository, error) {
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	return &PostgresOrderRepository{db: db}, nil
+	return db, nil
+}
+
+// OpenSQLite открывает файл базы из SQLITE_PATH.
+// WAL позволяет читать во время записи, а _txlock=immediate сразу берёт блокировку
+// записи в начале транзакции, чтобы параллельные транзакции не упирались в SQLITE_BUSY
+// при повышении блокировки.
+func OpenSQLite() (*sql.DB, error) {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = defaultSQLitePath
+	}
+	return openSQLiteFile(path)
+}
+
+// sqliteDSN возвращает строку подключения к файлу базы SQLite
+func sqliteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on&_txlock=immediate", path)
+}
+
+func openSQLiteFile(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %v", err)
+	}
+
+	return db, nil
 }
@@ -0,0 +1,713 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"order_service/internal/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryReservation struct {
+	orderID   int64
+	productID int64
+	quantity  int64
+	createdAt time.Time
+}
+
+type memoryProduct struct {
+	name  string
+	stock int64
+}
+
+type idempotencyKey struct {
+	userID int64
+	key    string
+}
+
+// memoryTxKey — ключ контекста, под которым лежит открытая транзакция
+type memoryTxKey struct{}
+
+// memoryTx — открытая транзакция. Изменения копятся в ней поверх зафиксированных
+// данных: чтения в транзакции сначала смотрят сюда, остальные видят их только после
+// фиксации, а откат просто отбрасывает транзакцию.
+type memoryTx struct {
+	repo         *MemoryOrderRepository
+	orders       map[int64]*entity.Order // новые и изменённые заказы
+	archived     map[int64]*entity.Order // заказы, перенесённые в архив
+	history      []entity.StatusChange
+	reservations []memoryReservation // новые резервы
+	released     map[int64]bool      // заказы, с которых сняты зафиксированные резервы
+	sagas        map[int64]*entity.OrderSaga
+	events       []entity.OrderEvent
+	sent         map[int64]bool
+	idempotency  map[idempotencyKey]*entity.IdempotencyRecord // nil — ключ удалён
+}
+
+func newMemoryTx(repo *MemoryOrderRepository) *memoryTx {
+	return &memoryTx{
+		repo:        repo,
+		orders:      map[int64]*entity.Order{},
+		archived:    map[int64]*entity.Order{},
+		released:    map[int64]bool{},
+		sagas:       map[int64]*entity.OrderSaga{},
+		sent:        map[int64]bool{},
+		idempotency: map[idempotencyKey]*entity.IdempotencyRecord{},
+	}
+}
+
+// MemoryOrderRepository — потокобезопасная реализация OrderRepository в памяти
+// для локального запуска и тестов. Повторяет поведение Postgres: ID выдаются
+// последовательно с 1, отсутствующие записи возвращают sql.ErrNoRows.
+//
+// Изменения выполняются строго по одной транзакции: WithinTransaction ждёт завершения
+// предыдущей, а метод, вызванный вне транзакции, открывает собственную. Чтения внутри
+// транзакции видят её изменения, чтения вне неё — только зафиксированные данные.
+// Если fn вернула ошибку, изменения транзакции отбрасываются.
+type MemoryOrderRepository struct {
+	mu   sync.Mutex // защищает данные
+	txMu sync.Mutex // удерживается открытой транзакцией
+
+	orders       map[int64]*entity.Order
+	archive      map[int64]*entity.Order
+	history      []entity.StatusChange
+	reservations []memoryReservation
+	products     map[int64]memoryProduct
+	sagas        map[int64]*entity.OrderSaga
+	outbox       []entity.OutboxEvent
+	sent         map[int64]bool
+	idempotency  map[idempotencyKey]*entity.IdempotencyRecord
+
+	// Счётчики ID не откатываются вместе с транзакцией, как последовательности в Postgres
+	nextOrderID   int64
+	nextItemID    int64
+	nextHistoryID int64
+	nextSagaID    int64
+	nextEventID   int64
+}
+
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		orders:      map[int64]*entity.Order{},
+		archive:     map[int64]*entity.Order{},
+		products:    map[int64]memoryProduct{},
+		sagas:       map[int64]*entity.OrderSaga{},
+		sent:        map[int64]bool{},
+		idempotency: map[idempotencyKey]*entity.IdempotencyRecord{},
+	}
+}
+
+// SetProductStock задаёт остаток товара (аналог таблицы products)
+func (r *MemoryOrderRepository) SetProductStock(productID int64, name string, stock int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products[productID] = memoryProduct{name: name, stock: stock}
+}
+
+func (r *MemoryOrderRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.current(ctx) != nil {
+		return fn(ctx)
+	}
+
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	pending := newMemoryTx(r)
+	if err := fn(context.WithValue(ctx, memoryTxKey{}, pending)); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commit(pending)
+	return nil
+}
+
+// commit переносит изменения транзакции в зафиксированные данные. Вызывается под r.mu.
+func (r *MemoryOrderRepository) commit(tx *memoryTx) {
+	for id, order := range tx.orders {
+		r.orders[id] = order
+	}
+	for id, order := range tx.archived {
+		delete(r.orders, id)
+		r.archive[id] = order
+	}
+	r.history = append(r.history, tx.history...)
+	r.reservations = append(filterReservations(r.reservations, func(res memoryReservation) bool {
+		return !tx.released[res.orderID]
+	}), tx.reservations...)
+	for id, saga := range tx.sagas {
+		r.sagas[id] = saga
+	}
+	for _, event := range tx.events {
+		r.appendEvent(event)
+	}
+	for id := range tx.sent {
+		r.sent[id] = true
+	}
+	for k, record := range tx.idempotency {
+		if record == nil {
+			delete(r.idempotency, k)
+		} else {
+			r.idempotency[k] = record
+		}
+	}
+}
+
+// write выполняет изменение fn под r.mu в транзакции из ctx или в собственной
+func (r *MemoryOrderRepository) write(ctx context.Context, fn func(tx *memoryTx) error) error {
+	tx := r.current(ctx)
+	if tx == nil {
+		return r.WithinTransaction(ctx, func(ctx context.Context) error {
+			return r.write(ctx, fn)
+		})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fn(tx)
+}
+
+// current возвращает открытую транзакцию этого репозитория из ctx или nil
+func (r *MemoryOrderRepository) current(ctx context.Context) *memoryTx {
+	if pending, ok := ctx.Value(memoryTxKey{}).(*memoryTx); ok && pending.repo == r {
+		return pending
+	}
+	return nil
+}
+
+// lookupOrder ищет заказ среди действующих и архивных с учётом транзакции tx (может быть nil).
+// Возвращённый заказ изменять нельзя. Вызывается под r.mu.
+func (r *MemoryOrderRepository) lookupOrder(tx *memoryTx, orderID int64) (*entity.Order, bool) {
+	if tx != nil {
+		if order, ok := tx.orders[orderID]; ok {
+			return order, true
+		}
+		if order, ok := tx.archived[orderID]; ok {
+			return order, true
+		}
+	}
+	if order, ok := r.orders[orderID]; ok {
+		return order, true
+	}
+	order, ok := r.archive[orderID]
+	return order, ok
+}
+
+// findLiveOrder ищет действующий (не архивный) заказ с учётом транзакции tx (может быть nil).
+// Возвращённый заказ изменять нельзя. Вызывается под r.mu.
+func (r *MemoryOrderRepository) findLiveOrder(tx *memoryTx, orderID int64) (*entity.Order, bool) {
+	if tx != nil {
+		if order, ok := tx.orders[orderID]; ok {
+			return order, true
+		}
+		if _, ok := tx.archived[orderID]; ok {
+			return nil, false
+		}
+	}
+	order, ok := r.orders[orderID]
+	return order, ok
+}
+
+// liveOrder возвращает копию действующего заказа в транзакции tx, которую можно изменять.
+// Вызывается под r.mu.
+func (r *MemoryOrderRepository) liveOrder(tx *memoryTx, orderID int64) (*entity.Order, bool) {
+	order, ok := r.findLiveOrder(tx, orderID)
+	if !ok {
+		return nil, false
+	}
+	if _, staged := tx.orders[orderID]; !staged {
+		order = copyOrder(order)
+		tx.orders[orderID] = order
+	}
+	return order, true
+}
+
+// liveOrders возвращает действующие заказы с учётом транзакции tx (может быть nil).
+// Возвращённые заказы изменять нельзя. Вызывается под r.mu.
+func (r *MemoryOrderRepository) liveOrders(tx *memoryTx) []*entity.Order {
+	orders := make([]*entity.Order, 0, len(r.orders))
+	for id, order := range r.orders {
+		if tx != nil {
+			if _, ok := tx.orders[id]; ok {
+				continue
+			}
+			if _, ok := tx.archived[id]; ok {
+				continue
+			}
+		}
+		orders = append(orders, order)
+	}
+	if tx != nil {
+		for _, order := range tx.orders {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+// liveReservations возвращает резервы с учётом транзакции tx (может быть nil). Вызывается под r.mu.
+func (r *MemoryOrderRepository) liveReservations(tx *memoryTx) []memoryReservation {
+	if tx == nil {
+		return r.reservations
+	}
+	var reservations []memoryReservation
+	for _, res := range r.reservations {
+		if !tx.released[res.orderID] {
+			reservations = append(reservations, res)
+		}
+	}
+	return append(reservations, tx.reservations...)
+}
+
+// release снимает в транзакции tx все резервы заказа. Вызывается под r.mu.
+func (tx *memoryTx) release(orderID int64) {
+	tx.released[orderID] = true
+	tx.reservations = filterReservations(tx.reservations, func(res memoryReservation) bool { return res.orderID != orderID })
+}
+
+// Create сохраняет заказ в транзакции из ctx или в собственной. ID выдаётся сразу
+// и не переиспользуется после отката, как у SERIAL в Postgres.
+func (r *MemoryOrderRepository) Create(ctx context.Context, order *entity.Order) (*entity.Order, error) {
+	err := r.write(ctx, func(tx *memoryTx) error {
+		r.nextOrderID++
+		order.ID = r.nextOrderID
+		order.Version = 1
+		for i := range order.Items {
+			r.nextItemID++
+			order.Items[i].ID = r.nextItemID
+		}
+		tx.orders[order.ID] = copyOrder(order)
+		tx.events = append(tx.events, entity.OrderCreatedEvent(order))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (r *MemoryOrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, orderID, false)
+}
+
+// GetOrderByIDIncludingDeleted возвращает заказ, даже если он мягко удалён
+func (r *MemoryOrderRepository) GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, orderID, true)
+}
+
+func (r *MemoryOrderRepository) getOrderByID(ctx context.Context, orderID int64, includeDeleted bool) (*entity.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.lookupOrder(r.current(ctx), orderID)
+	if !ok || (order.DeletedAt != nil && !includeDeleted) {
+		return nil, ErrOrderNotFound
+	}
+	return copyOrder(order), nil
+}
+
+// Delete помечает заказ удалённым и записывает событие order.deleted;
+// заказ, ожидающий оплаты, не удаляется
+func (r *MemoryOrderRepository) Delete(ctx context.Context, orderID int64) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		order, ok := r.liveOrder(tx, orderID)
+		if !ok || order.DeletedAt != nil {
+			return ErrOrderNotFound
+		}
+		if order.Status == entity.OrderStatusPending {
+			return ErrOrderPending
+		}
+		now := time.Now().UTC()
+		order.DeletedAt = &now
+		order.Version++
+		tx.events = append(tx.events, entity.OrderDeletedEvent(order.UserID, orderID, order.Status, now))
+		return nil
+	})
+}
+
+func (r *MemoryOrderRepository) Restore(ctx context.Context, orderID int64) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		order, ok := r.liveOrder(tx, orderID)
+		if !ok || order.DeletedAt == nil {
+			return ErrOrderNotFound
+		}
+		if order.Status == entity.OrderStatusPending {
+			return ErrOrderPending
+		}
+		order.DeletedAt = nil
+		order.Version++
+		tx.events = append(tx.events, entity.OrderRestoredEvent(order.UserID, orderID, order.Status, time.Now().UTC()))
+		return nil
+	})
+}
+
+// ArchiveOrders переносит завершённые заказы старше age в архив.
+// История статусов остаётся в общем списке: GetStatusHistory читает её для любых заказов.
+func (r *MemoryOrderRepository) ArchiveOrders(ctx context.Context, age time.Duration, limit int) ([]int64, error) {
+	var orderIDs []int64
+	err := r.write(ctx, func(tx *memoryTx) error {
+		orderIDs = nil
+		deadline := time.Now().Add(-age)
+		for _, order := range r.liveOrders(tx) {
+			if order.Status.Archivable() && order.CreatedAt.Before(deadline) {
+				orderIDs = append(orderIDs, order.ID)
+			}
+		}
+		sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+		if len(orderIDs) > limit {
+			orderIDs = orderIDs[:limit]
+		}
+
+		now := time.Now().UTC()
+		for _, id := range orderIDs {
+			order, _ := r.liveOrder(tx, id)
+			order.ArchivedAt = &now
+			delete(tx.orders, id)
+			tx.archived[id] = order
+			tx.release(id)
+		}
+		return nil
+	})
+	return orderIDs, err
+}
+
+// ReserveStock резервирует товар, если остаток stock с учётом резервов это позволяет
+func (r *MemoryOrderRepository) ReserveStock(ctx context.Context, orderID, productID int64, quantity int64, stock int64) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		if stock-sumReserved(r.liveReservations(tx), productID) < quantity {
+			return fmt.Errorf("%w: product %d", ErrInsufficientStock, productID)
+		}
+		tx.reservations = append(tx.reservations, memoryReservation{
+			orderID:   orderID,
+			productID: productID,
+			quantity:  quantity,
+			createdAt: time.Now(),
+		})
+		return nil
+	})
+}
+
+func (r *MemoryOrderRepository) GetReservedStock(ctx context.Context, productIDs []int64) (map[int64]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reservations := r.liveReservations(r.current(ctx))
+	reserved := make(map[int64]int64, len(productIDs))
+	for _, productID := range productIDs {
+		if sum := sumReserved(reservations, productID); sum > 0 {
+			reserved[productID] = sum
+		}
+	}
+	return reserved, nil
+}
+
+func (r *MemoryOrderRepository) GetAvailableStock(ctx context.Context, productID int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[productID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return product.stock - sumReserved(r.liveReservations(r.current(ctx)), productID), nil
+}
+
+// GetExpiredOrders возвращает ID pending-заказов с резервами старше ttl без повторов.
+// Резервы снимает смена статуса на expired.
+func (r *MemoryOrderRepository) GetExpiredOrders(ctx context.Context, ttl time.Duration) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := r.current(ctx)
+	deadline := time.Now().Add(-ttl)
+	seen := map[int64]bool{}
+	var orderIDs []int64
+	for _, res := range r.liveReservations(tx) {
+		if seen[res.orderID] || !res.createdAt.Before(deadline) {
+			continue
+		}
+		order, ok := r.findLiveOrder(tx, res.orderID)
+		if ok && order.Status == entity.OrderStatusPending && order.DeletedAt == nil {
+			seen[res.orderID] = true
+			orderIDs = append(orderIDs, res.orderID)
+		}
+	}
+	sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+	return orderIDs, nil
+}
+
+func (r *MemoryOrderRepository) ReleaseReservations(ctx context.Context, orderID int64) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		tx.release(orderID)
+		return nil
+	})
+}
+
+func (r *MemoryOrderRepository) UpdateOrderStatus(ctx context.Context, change *entity.StatusChange) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		return r.changeStatus(tx, change, nil)
+	})
+}
+
+func (r *MemoryOrderRepository) CancelOrder(ctx context.Context, userID int64, orderID int64, expectedVersion int64) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		return r.changeStatus(tx, &entity.StatusChange{
+			OrderID:         orderID,
+			NewStatus:       entity.OrderStatusCanceled,
+			Actor:           entity.UserActor(userID),
+			ExpectedVersion: expectedVersion,
+		}, &userID)
+	})
+}
+
+// changeStatus повторяет PostgresOrderRepository.changeStatus. Вызывается под r.mu.
+func (r *MemoryOrderRepository) changeStatus(tx *memoryTx, change *entity.StatusChange, userID *int64) error {
+	current, ok := r.findLiveOrder(tx, change.OrderID)
+	if !ok || current.DeletedAt != nil || (userID != nil && current.UserID != *userID) {
+		return ErrOrderNotFound
+	}
+	if change.ExpectedVersion != 0 && change.ExpectedVersion != current.Version {
+		return ErrConcurrentModification
+	}
+	if err := current.Status.TransitionTo(change.NewStatus); err != nil {
+		return err
+	}
+
+	order, _ := r.liveOrder(tx, change.OrderID)
+	change.OldStatus = order.Status
+	order.Status = change.NewStatus
+	order.Version++
+
+	if change.OldStatus == entity.OrderStatusPending {
+		tx.release(order.ID)
+	}
+
+	r.nextHistoryID++
+	change.ID = r.nextHistoryID
+	change.CreatedAt = time.Now().UTC()
+	tx.history = append(tx.history, *change)
+
+	tx.events = append(tx.events, entity.StatusChangedEvent(order.UserID, change))
+	return nil
+}
+
+func (r *MemoryOrderRepository) GetStatusHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := r.history
+	if tx := r.current(ctx); tx != nil {
+		changes = append(append([]entity.StatusChange(nil), r.history...), tx.history...)
+	}
+	history := []entity.StatusChange{}
+	for _, c := range changes {
+		if c.OrderID == orderID {
+			history = append(history, c)
+		}
+	}
+	return history, nil
+}
+
+func (r *MemoryOrderRepository) GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) ([]entity.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var orders []entity.Order
+	for _, order := range r.liveOrders(r.current(ctx)) {
+		if !query.Matches(*order) {
+			continue
+		}
+		if query.After != nil && !query.Less(*query.After, entity.CursorOf(*order)) {
+			continue
+		}
+		orders = append(orders, *copyOrder(order))
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return query.Less(entity.CursorOf(orders[i]), entity.CursorOf(orders[j]))
+	})
+	if query.Limit > 0 && len(orders) > query.Limit {
+		orders = orders[:query.Limit]
+	}
+	return orders, nil
+}
+
+// SAGA methods
+
+func (r *MemoryOrderRepository) CreateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		r.nextSagaID++
+		now := time.Now().UTC()
+		saga.ID = r.nextSagaID
+		saga.CreatedAt, saga.UpdatedAt = now, now
+		stored := *saga
+		tx.sagas[saga.ID] = &stored
+		return nil
+	})
+}
+
+func (r *MemoryOrderRepository) UpdateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		stored, ok := tx.sagas[saga.ID]
+		if !ok {
+			stored, ok = r.sagas[saga.ID]
+		}
+		if !ok {
+			return sql.ErrNoRows
+		}
+		saga.CreatedAt = stored.CreatedAt
+		saga.UpdatedAt = time.Now().UTC()
+		updated := *saga
+		tx.sagas[saga.ID] = &updated
+		return nil
+	})
+}
+
+func (r *MemoryOrderRepository) GetStaleSagas(ctx context.Context, olderThan time.Duration) ([]entity.OrderSaga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := r.current(ctx)
+	deadline := time.Now().Add(-olderThan)
+	var sagas []entity.OrderSaga
+	all := r.sagas
+	if tx != nil {
+		all = make(map[int64]*entity.OrderSaga, len(r.sagas)+len(tx.sagas))
+		for id, saga := range r.sagas {
+			all[id] = saga
+		}
+		for id, saga := range tx.sagas {
+			all[id] = saga
+		}
+	}
+	for _, saga := range all {
+		unfinished := saga.Status == entity.SagaStatusRunning || saga.Status == entity.SagaStatusCompensating
+		if unfinished && saga.UpdatedAt.Before(deadline) {
+			sagas = append(sagas, *saga)
+		}
+	}
+	sort.Slice(sagas, func(i, j int) bool { return sagas[i].ID < sagas[j].ID })
+	return sagas, nil
+}
+
+// OUTBOX methods
+
+// appendEvent записывает событие в outbox при фиксации транзакции. Вызывается под r.mu.
+func (r *MemoryOrderRepository) appendEvent(event entity.OrderEvent) {
+	record, err := entity.NewOutboxEvent(event)
+	if err != nil {
+		return
+	}
+	r.nextEventID++
+	record.ID = r.nextEventID
+	record.CreatedAt = time.Now().UTC()
+	r.outbox = append(r.outbox, *record)
+}
+
+func (r *MemoryOrderRepository) GetUnsentEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := r.current(ctx)
+	var events []entity.OutboxEvent
+	for _, event := range r.outbox {
+		if len(events) == limit {
+			break
+		}
+		if !r.sent[event.ID] && (tx == nil || !tx.sent[event.ID]) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *MemoryOrderRepository) MarkEventsSent(ctx context.Context, ids []int64) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		for _, id := range ids {
+			tx.sent[id] = true
+		}
+		return nil
+	})
+}
+
+// IDEMPOTENCY methods
+
+// idempotencyRecord возвращает запись ключа с учётом транзакции tx. Вызывается под r.mu.
+func (r *MemoryOrderRepository) idempotencyRecord(tx *memoryTx, k idempotencyKey) (*entity.IdempotencyRecord, bool) {
+	if record, ok := tx.idempotency[k]; ok {
+		return record, record != nil
+	}
+	record, ok := r.idempotency[k]
+	return record, ok
+}
+
+func (r *MemoryOrderRepository) ClaimIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord, ttl time.Duration) (*entity.IdempotencyRecord, error) {
+	var found *entity.IdempotencyRecord
+	err := r.write(ctx, func(tx *memoryTx) error {
+		now := time.Now().UTC()
+		k := idempotencyKey{userID: record.UserID, key: record.Key}
+		if existing, ok := r.idempotencyRecord(tx, k); ok && existing.ExpiresAt.After(now) {
+			c := *existing
+			found = &c
+			return nil
+		}
+
+		stored := *record
+		stored.Response = nil
+		stored.CreatedAt = now
+		stored.ExpiresAt = now.Add(ttl)
+		tx.idempotency[k] = &stored
+		return nil
+	})
+	return found, err
+}
+
+func (r *MemoryOrderRepository) SaveIdempotentResponse(ctx context.Context, userID int64, key string, response []byte) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		k := idempotencyKey{userID: userID, key: key}
+		if record, ok := r.idempotencyRecord(tx, k); ok {
+			updated := *record
+			updated.Response = append([]byte(nil), response...)
+			tx.idempotency[k] = &updated
+		}
+		return nil
+	})
+}
+
+func (r *MemoryOrderRepository) ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	return r.write(ctx, func(tx *memoryTx) error {
+		k := idempotencyKey{userID: userID, key: key}
+		if record, ok := r.idempotencyRecord(tx, k); ok && record.Response == nil {
+			tx.idempotency[k] = nil
+		}
+		return nil
+	})
+}
+
+func copyOrder(order *entity.Order) *entity.Order {
+	c := *order
+	c.Items = append([]entity.OrderItem(nil), order.Items...)
+	return &c
+}
+
+func sumReserved(reservations []memoryReservation, productID int64) int64 {
+	var sum int64
+	for _, res := range reservations {
+		if res.productID == productID {
+			sum += res.quantity
+		}
+	}
+	return sum
+}
+
+func filterReservations(reservations []memoryReservation, keep func(memoryReservation) bool) []memoryReservation {
+	kept := reservations[:0]
+	for _, res := range reservations {
+		if keep(res) {
+			kept = append(kept, res)
+		}
+	}
+	return kept
+}
@@ -11,13 +11,51 @@ package mocks
 
 import (
 	context "context"
-	sql "database/sql"
 	entity "order_service/internal/entity"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
 
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+	isgomock struct{}
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// WithinTransaction mocks base method.
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockTransactorMockRecorder) WithinTransaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockTransactor)(nil).WithinTransaction), ctx, fn)
+}
+
 // MockOrderRepository is a mock of OrderRepository interface.
 type MockOrderRepository struct {
 	ctrl     *gomock.Controller
@@ -42,77 +80,91 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
-// BeginTransaction mocks base method.
-func (m *MockOrderRepository) BeginTransaction() (*sql.Tx, error) {
+// ArchiveOrders mocks base method.
+func (m *MockOrderRepository) ArchiveOrders(ctx context.Context, age time.Duration, limit int) ([]int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BeginTransaction")
-	ret0, _ := ret[0].(*sql.Tx)
+	ret := m.ctrl.Call(m, "ArchiveOrders", ctx, age, limit)
+	ret0, _ := ret[0].([]int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// BeginTransaction indicates an expected call of BeginTransaction.
-func (mr *MockOrderRepositoryMockRecorder) BeginTransaction() *gomock.Call {
+// ArchiveOrders indicates an expected call of ArchiveOrders.
+func (mr *MockOrderRepositoryMockRecorder) ArchiveOrders(ctx, age, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTransaction", reflect.TypeOf((*MockOrderRepository)(nil).BeginTransaction))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveOrders", reflect.TypeOf((*MockOrderRepository)(nil).ArchiveOrders), ctx, age, limit)
 }
 
 // CancelOrder mocks base method.
-func (m *MockOrderRepository) CancelOrder(userID, orderID int64) error {
+func (m *MockOrderRepository) CancelOrder(ctx context.Context, userID, orderID, expectedVersion int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CancelOrder", userID, orderID)
+	ret := m.ctrl.Call(m, "CancelOrder", ctx, userID, orderID, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CancelOrder indicates an expected call of CancelOrder.
-func (mr *MockOrderRepositoryMockRecorder) CancelOrder(userID, orderID any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) CancelOrder(ctx, userID, orderID, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockOrderRepository)(nil).CancelOrder), userID, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockOrderRepository)(nil).CancelOrder), ctx, userID, orderID, expectedVersion)
 }
 
-// ClearExpiredReservations mocks base method.
-func (m *MockOrderRepository) ClearExpiredReservations(ctx context.Context) ([]int64, error) {
+// ClaimIdempotencyKey mocks base method.
+func (m *MockOrderRepository) ClaimIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord, ttl time.Duration) (*entity.IdempotencyRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ClearExpiredReservations", ctx)
-	ret0, _ := ret[0].([]int64)
+	ret := m.ctrl.Call(m, "ClaimIdempotencyKey", ctx, record, ttl)
+	ret0, _ := ret[0].(*entity.IdempotencyRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ClearExpiredReservations indicates an expected call of ClearExpiredReservations.
-func (mr *MockOrderRepositoryMockRecorder) ClearExpiredReservations(ctx any) *gomock.Call {
+// ClaimIdempotencyKey indicates an expected call of ClaimIdempotencyKey.
+func (mr *MockOrderRepositoryMockRecorder) ClaimIdempotencyKey(ctx, record, ttl any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearExpiredReservations", reflect.TypeOf((*MockOrderRepository)(nil).ClearExpiredReservations), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimIdempotencyKey", reflect.TypeOf((*MockOrderRepository)(nil).ClaimIdempotencyKey), ctx, record, ttl)
 }
 
 // Create mocks base method.
-func (m *MockOrderRepository) Create(order *entity.Order) (*entity.Order, error) {
+func (m *MockOrderRepository) Create(ctx context.Context, order *entity.Order) (*entity.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", order)
+	ret := m.ctrl.Call(m, "Create", ctx, order)
 	ret0, _ := ret[0].(*entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockOrderRepositoryMockRecorder) Create(order any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) Create(ctx, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderRepository)(nil).Create), ctx, order)
+}
+
+// CreateSaga mocks base method.
+func (m *MockOrderRepository) CreateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSaga", ctx, saga)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSaga indicates an expected call of CreateSaga.
+func (mr *MockOrderRepositoryMockRecorder) CreateSaga(ctx, saga any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderRepository)(nil).Create), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSaga", reflect.TypeOf((*MockOrderRepository)(nil).CreateSaga), ctx, saga)
 }
 
 // Delete mocks base method.
-func (m *MockOrderRepository) Delete(orderID int64) error {
+func (m *MockOrderRepository) Delete(ctx context.Context, orderID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", orderID)
+	ret := m.ctrl.Call(m, "Delete", ctx, orderID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockOrderRepositoryMockRecorder) Delete(orderID any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) Delete(ctx, orderID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrderRepository)(nil).Delete), orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrderRepository)(nil).Delete), ctx, orderID)
 }
 
 // GetAvailableStock mocks base method.
@@ -130,60 +182,435 @@ func (mr *MockOrderRepositoryMockRecorder) GetAvailableStock(ctx, productID any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAvailableStock", reflect.TypeOf((*MockOrderRepository)(nil).GetAvailableStock), ctx, productID)
 }
 
+// GetExpiredOrders mocks base method.
+func (m *MockOrderRepository) GetExpiredOrders(ctx context.Context, ttl time.Duration) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredOrders", ctx, ttl)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredOrders indicates an expected call of GetExpiredOrders.
+func (mr *MockOrderRepositoryMockRecorder) GetExpiredOrders(ctx, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetExpiredOrders), ctx, ttl)
+}
+
 // GetOrderByID mocks base method.
-func (m *MockOrderRepository) GetOrderByID(orderID int64) (*entity.Order, error) {
+func (m *MockOrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderByID", orderID)
+	ret := m.ctrl.Call(m, "GetOrderByID", ctx, orderID)
 	ret0, _ := ret[0].(*entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrderByID indicates an expected call of GetOrderByID.
-func (mr *MockOrderRepositoryMockRecorder) GetOrderByID(orderID any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) GetOrderByID(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderByID), ctx, orderID)
+}
+
+// GetOrderByIDIncludingDeleted mocks base method.
+func (m *MockOrderRepository) GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByIDIncludingDeleted", ctx, orderID)
+	ret0, _ := ret[0].(*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByIDIncludingDeleted indicates an expected call of GetOrderByIDIncludingDeleted.
+func (mr *MockOrderRepositoryMockRecorder) GetOrderByIDIncludingDeleted(ctx, orderID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderByID), orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByIDIncludingDeleted", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderByIDIncludingDeleted), ctx, orderID)
 }
 
 // GetOrdersByUserID mocks base method.
-func (m *MockOrderRepository) GetOrdersByUserID(userID int64) ([]entity.Order, error) {
+func (m *MockOrderRepository) GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) ([]entity.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrdersByUserID", userID)
+	ret := m.ctrl.Call(m, "GetOrdersByUserID", ctx, query)
 	ret0, _ := ret[0].([]entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrdersByUserID indicates an expected call of GetOrdersByUserID.
-func (mr *MockOrderRepositoryMockRecorder) GetOrdersByUserID(userID any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) GetOrdersByUserID(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrdersByUserID), ctx, query)
+}
+
+// GetReservedStock mocks base method.
+func (m *MockOrderRepository) GetReservedStock(ctx context.Context, productIDs []int64) (map[int64]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservedStock", ctx, productIDs)
+	ret0, _ := ret[0].(map[int64]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReservedStock indicates an expected call of GetReservedStock.
+func (mr *MockOrderRepositoryMockRecorder) GetReservedStock(ctx, productIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservedStock", reflect.TypeOf((*MockOrderRepository)(nil).GetReservedStock), ctx, productIDs)
+}
+
+// GetStaleSagas mocks base method.
+func (m *MockOrderRepository) GetStaleSagas(ctx context.Context, olderThan time.Duration) ([]entity.OrderSaga, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStaleSagas", ctx, olderThan)
+	ret0, _ := ret[0].([]entity.OrderSaga)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStaleSagas indicates an expected call of GetStaleSagas.
+func (mr *MockOrderRepositoryMockRecorder) GetStaleSagas(ctx, olderThan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStaleSagas", reflect.TypeOf((*MockOrderRepository)(nil).GetStaleSagas), ctx, olderThan)
+}
+
+// GetStatusHistory mocks base method.
+func (m *MockOrderRepository) GetStatusHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusHistory", ctx, orderID)
+	ret0, _ := ret[0].([]entity.StatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusHistory indicates an expected call of GetStatusHistory.
+func (mr *MockOrderRepositoryMockRecorder) GetStatusHistory(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusHistory", reflect.TypeOf((*MockOrderRepository)(nil).GetStatusHistory), ctx, orderID)
+}
+
+// GetUnsentEvents mocks base method.
+func (m *MockOrderRepository) GetUnsentEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnsentEvents", ctx, limit)
+	ret0, _ := ret[0].([]entity.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnsentEvents indicates an expected call of GetUnsentEvents.
+func (mr *MockOrderRepositoryMockRecorder) GetUnsentEvents(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnsentEvents", reflect.TypeOf((*MockOrderRepository)(nil).GetUnsentEvents), ctx, limit)
+}
+
+// MarkEventsSent mocks base method.
+func (m *MockOrderRepository) MarkEventsSent(ctx context.Context, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventsSent", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventsSent indicates an expected call of MarkEventsSent.
+func (mr *MockOrderRepositoryMockRecorder) MarkEventsSent(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventsSent", reflect.TypeOf((*MockOrderRepository)(nil).MarkEventsSent), ctx, ids)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockOrderRepository) ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockOrderRepositoryMockRecorder) ReleaseIdempotencyKey(ctx, userID, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockOrderRepository)(nil).ReleaseIdempotencyKey), ctx, userID, key)
+}
+
+// ReleaseReservations mocks base method.
+func (m *MockOrderRepository) ReleaseReservations(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseReservations", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseReservations indicates an expected call of ReleaseReservations.
+func (mr *MockOrderRepositoryMockRecorder) ReleaseReservations(ctx, orderID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrdersByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseReservations", reflect.TypeOf((*MockOrderRepository)(nil).ReleaseReservations), ctx, orderID)
 }
 
 // ReserveStock mocks base method.
-func (m *MockOrderRepository) ReserveStock(ctx context.Context, tx *sql.Tx, orderID, productID, quantity int64) error {
+func (m *MockOrderRepository) ReserveStock(ctx context.Context, orderID, productID, quantity, stock int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReserveStock", ctx, tx, orderID, productID, quantity)
+	ret := m.ctrl.Call(m, "ReserveStock", ctx, orderID, productID, quantity, stock)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ReserveStock indicates an expected call of ReserveStock.
-func (mr *MockOrderRepositoryMockRecorder) ReserveStock(ctx, tx, orderID, productID, quantity any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) ReserveStock(ctx, orderID, productID, quantity, stock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveStock", reflect.TypeOf((*MockOrderRepository)(nil).ReserveStock), ctx, orderID, productID, quantity, stock)
+}
+
+// Restore mocks base method.
+func (m *MockOrderRepository) Restore(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockOrderRepositoryMockRecorder) Restore(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockOrderRepository)(nil).Restore), ctx, orderID)
+}
+
+// SaveIdempotentResponse mocks base method.
+func (m *MockOrderRepository) SaveIdempotentResponse(ctx context.Context, userID int64, key string, response []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", ctx, userID, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockOrderRepositoryMockRecorder) SaveIdempotentResponse(ctx, userID, key, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockOrderRepository)(nil).SaveIdempotentResponse), ctx, userID, key, response)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockOrderRepository) UpdateOrderStatus(ctx context.Context, change *entity.StatusChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateOrderStatus(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateOrderStatus), ctx, change)
+}
+
+// UpdateSaga mocks base method.
+func (m *MockOrderRepository) UpdateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSaga", ctx, saga)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSaga indicates an expected call of UpdateSaga.
+func (mr *MockOrderRepositoryMockRecorder) UpdateSaga(ctx, saga any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSaga", reflect.TypeOf((*MockOrderRepository)(nil).UpdateSaga), ctx, saga)
+}
+
+// WithinTransaction mocks base method.
+func (m *MockOrderRepository) WithinTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockOrderRepositoryMockRecorder) WithinTransaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockOrderRepository)(nil).WithinTransaction), ctx, fn)
+}
+
+// MockSagaRepository is a mock of SagaRepository interface.
+type MockSagaRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSagaRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSagaRepositoryMockRecorder is the mock recorder for MockSagaRepository.
+type MockSagaRepositoryMockRecorder struct {
+	mock *MockSagaRepository
+}
+
+// NewMockSagaRepository creates a new mock instance.
+func NewMockSagaRepository(ctrl *gomock.Controller) *MockSagaRepository {
+	mock := &MockSagaRepository{ctrl: ctrl}
+	mock.recorder = &MockSagaRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSagaRepository) EXPECT() *MockSagaRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSaga mocks base method.
+func (m *MockSagaRepository) CreateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSaga", ctx, saga)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSaga indicates an expected call of CreateSaga.
+func (mr *MockSagaRepositoryMockRecorder) CreateSaga(ctx, saga any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSaga", reflect.TypeOf((*MockSagaRepository)(nil).CreateSaga), ctx, saga)
+}
+
+// GetStaleSagas mocks base method.
+func (m *MockSagaRepository) GetStaleSagas(ctx context.Context, olderThan time.Duration) ([]entity.OrderSaga, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStaleSagas", ctx, olderThan)
+	ret0, _ := ret[0].([]entity.OrderSaga)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStaleSagas indicates an expected call of GetStaleSagas.
+func (mr *MockSagaRepositoryMockRecorder) GetStaleSagas(ctx, olderThan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStaleSagas", reflect.TypeOf((*MockSagaRepository)(nil).GetStaleSagas), ctx, olderThan)
+}
+
+// UpdateSaga mocks base method.
+func (m *MockSagaRepository) UpdateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSaga", ctx, saga)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSaga indicates an expected call of UpdateSaga.
+func (mr *MockSagaRepositoryMockRecorder) UpdateSaga(ctx, saga any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSaga", reflect.TypeOf((*MockSagaRepository)(nil).UpdateSaga), ctx, saga)
+}
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetUnsentEvents mocks base method.
+func (m *MockOutboxRepository) GetUnsentEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnsentEvents", ctx, limit)
+	ret0, _ := ret[0].([]entity.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnsentEvents indicates an expected call of GetUnsentEvents.
+func (mr *MockOutboxRepositoryMockRecorder) GetUnsentEvents(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnsentEvents", reflect.TypeOf((*MockOutboxRepository)(nil).GetUnsentEvents), ctx, limit)
+}
+
+// MarkEventsSent mocks base method.
+func (m *MockOutboxRepository) MarkEventsSent(ctx context.Context, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEventsSent", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEventsSent indicates an expected call of MarkEventsSent.
+func (mr *MockOutboxRepositoryMockRecorder) MarkEventsSent(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventsSent", reflect.TypeOf((*MockOutboxRepository)(nil).MarkEventsSent), ctx, ids)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepository) ClaimIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord, ttl time.Duration) (*entity.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimIdempotencyKey", ctx, record, ttl)
+	ret0, _ := ret[0].(*entity.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimIdempotencyKey indicates an expected call of ClaimIdempotencyKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) ClaimIdempotencyKey(ctx, record, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).ClaimIdempotencyKey), ctx, record, ttl)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) ReleaseIdempotencyKey(ctx, userID, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveStock", reflect.TypeOf((*MockOrderRepository)(nil).ReserveStock), ctx, tx, orderID, productID, quantity)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).ReleaseIdempotencyKey), ctx, userID, key)
 }
 
-// UpdateOrder mocks base method.
-func (m *MockOrderRepository) UpdateOrder(order *entity.Order) error {
+// SaveIdempotentResponse mocks base method.
+func (m *MockIdempotencyRepository) SaveIdempotentResponse(ctx context.Context, userID int64, key string, response []byte) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateOrder", order)
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", ctx, userID, key, response)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateOrder indicates an expected call of UpdateOrder.
-func (mr *MockOrderRepositoryMockRecorder) UpdateOrder(order any) *gomock.Call {
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockIdempotencyRepositoryMockRecorder) SaveIdempotentResponse(ctx, userID, key, response any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockOrderRepository)(nil).UpdateOrder), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockIdempotencyRepository)(nil).SaveIdempotentResponse), ctx, userID, key, response)
 }
@@ -0,0 +1,984 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"order_service/internal/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Коллекции MongoDB
+const (
+	mongoOrders       = "orders"
+	mongoHistory      = "order_status_history"
+	mongoReservations = "reserved_stock"
+	mongoStockLocks   = "stock_locks"
+	mongoProducts     = "products"
+	mongoSagas        = "order_sagas"
+	mongoOutbox       = "outbox"
+	mongoIdempotency  = "idempotency_keys"
+	mongoCounters     = "counters"
+	mongoOrderItems   = "order_items" // только последовательность ID товаров: товары хранятся в заказе
+
+	mongoOrdersArchive  = "orders_archive"
+	mongoHistoryArchive = "order_status_history_archive"
+)
+
+// mongoOrder — заказ одним документом вместе с товарами
+type mongoOrder struct {
+	ID         int64              `bson:"_id"`
+	UserID     int64              `bson:"user_id"`
+	Items      []mongoOrderItem   `bson:"items"`
+	TotalPrice int64              `bson:"total_price"`
+	Currency   string             `bson:"currency"`
+	Status     entity.OrderStatus `bson:"status"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+	// ReservedAt — время первого резерва; снимается вместе с резервами
+	ReservedAt *time.Time `bson:"reserved_at,omitempty"`
+	Version    int64      `bson:"version,omitempty"`
+	DeletedAt  *time.Time `bson:"deleted_at,omitempty"`
+	ArchivedAt *time.Time `bson:"archived_at,omitempty"`
+}
+
+// version возвращает версию заказа. Документы, созданные до появления
+// версий, не содержат поля version и считаются версией 1, как после миграции в SQL.
+func (doc *mongoOrder) version() int64 {
+	if doc.Version == 0 {
+		return 1
+	}
+	return doc.Version
+}
+
+type mongoOrderItem struct {
+	ID        int64  `bson:"id"`
+	ProductID int64  `bson:"product_id"`
+	Name      string `bson:"name"`
+	Quantity  int64  `bson:"quantity"`
+	Price     int64  `bson:"price"`
+}
+
+type mongoReservation struct {
+	OrderID   int64     `bson:"order_id"`
+	ProductID int64     `bson:"product_id"`
+	Quantity  int64     `bson:"quantity"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type mongoStatusChange struct {
+	ID        int64              `bson:"_id"`
+	OrderID   int64              `bson:"order_id"`
+	OldStatus entity.OrderStatus `bson:"old_status,omitempty"`
+	NewStatus entity.OrderStatus `bson:"new_status"`
+	Actor     string             `bson:"actor"`
+	Reason    string             `bson:"reason,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+type mongoSaga struct {
+	ID         int64             `bson:"_id"`
+	UserID     int64             `bson:"user_id"`
+	OrderID    int64             `bson:"order_id"`
+	Step       entity.SagaStep   `bson:"step"`
+	Status     entity.SagaStatus `bson:"status"`
+	PaymentURL string            `bson:"payment_url"`
+	Error      string            `bson:"error"`
+	CreatedAt  time.Time         `bson:"created_at"`
+	UpdatedAt  time.Time         `bson:"updated_at"`
+}
+
+type mongoOutboxEvent struct {
+	ID        int64                 `bson:"_id"`
+	OrderID   int64                 `bson:"order_id"`
+	Type      entity.OrderEventType `bson:"event_type"`
+	Payload   string                `bson:"payload"`
+	CreatedAt time.Time             `bson:"created_at"`
+	SentAt    *time.Time            `bson:"sent_at"`
+}
+
+type mongoIdempotencyID struct {
+	UserID int64  `bson:"user_id"`
+	Key    string `bson:"key"`
+}
+
+type mongoIdempotencyRecord struct {
+	ID          mongoIdempotencyID `bson:"_id"`
+	RequestHash string             `bson:"request_hash"`
+	Response    []byte             `bson:"response,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	ExpiresAt   time.Time          `bson:"expires_at"`
+}
+
+// MongoOrderRepository — реализация OrderRepository на MongoDB.
+//
+// Заказ хранится одним документом с товарами. Запись заказа и резервов идёт
+// в многодокументной транзакции, поэтому нужен replica set. Резервы одного
+// товара сериализуются записью в его документ stock_locks: параллельная
+// транзакция получает WriteConflict и откатывается, а не продаёт лишнее.
+//
+// Резервы живут, пока заказ ожидает оплаты: GetExpiredOrders ищет заказы
+// по reserved_at, и резервы снимаются вместе с переводом заказа в expired.
+type MongoOrderRepository struct {
+	client *mongo.Client
+	db     *mongo.Database
+}
+
+func NewMongoOrderRepository(client *mongo.Client, dbName string) *MongoOrderRepository {
+	return &MongoOrderRepository{
+		client: client,
+		db:     client.Database(dbName),
+	}
+}
+
+// Коды ошибок MongoDB, при которых удалять индекс уже не нужно
+const (
+	mongoNamespaceNotFound = 26
+	mongoIndexNotFound     = 27
+)
+
+// EnsureIndexes создаёт индексы коллекций и удаляет устаревшие. Вызов идемпотентен.
+func (r *MongoOrderRepository) EnsureIndexes(ctx context.Context) error {
+	// TTL-индекс резервов удалял их в обход смены статуса, и заказ оставался
+	// в pending без резерва; теперь резервы снимает только перевод в expired
+	if err := r.dropIndex(ctx, mongoReservations, "expires_at_1"); err != nil {
+		return fmt.Errorf("failed to drop reservation TTL index: %w", err)
+	}
+
+	indexes := map[string][]mongo.IndexModel{
+		mongoOrders: {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "reserved_at", Value: 1}}},
+			{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		},
+		mongoHistory: {
+			{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+		mongoHistoryArchive: {
+			{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+		mongoReservations: {
+			{Keys: bson.D{{Key: "product_id", Value: 1}}},
+			{Keys: bson.D{{Key: "order_id", Value: 1}}},
+		},
+		mongoSagas: {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}}},
+		},
+		mongoOutbox: {
+			{Keys: bson.D{{Key: "sent_at", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+		mongoIdempotency: {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+	}
+	for collection, models := range indexes {
+		if _, err := r.db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("failed to create indexes for %s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+// dropIndex удаляет индекс name коллекции; отсутствие индекса или коллекции — не ошибка
+func (r *MongoOrderRepository) dropIndex(ctx context.Context, collection, name string) error {
+	_, err := r.db.Collection(collection).Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.HasErrorCode(mongoNamespaceNotFound) || cmdErr.HasErrorCode(mongoIndexNotFound)) {
+		return nil
+	}
+	return err
+}
+
+// NormalizeLegacyStatuses приводит устаревшее написание статуса "cancelled" к "canceled"
+// в заказах и истории, как миграция 0013 в SQL-базах. Вызов идемпотентен.
+func (r *MongoOrderRepository) NormalizeLegacyStatuses(ctx context.Context) error {
+	const legacy = "cancelled"
+	fields := map[string][]string{
+		mongoOrders:         {"status"},
+		mongoOrdersArchive:  {"status"},
+		mongoHistory:        {"old_status", "new_status"},
+		mongoHistoryArchive: {"old_status", "new_status"},
+	}
+	for collection, names := range fields {
+		for _, field := range names {
+			_, err := r.db.Collection(collection).UpdateMany(ctx,
+				bson.M{field: legacy},
+				bson.M{"$set": bson.M{field: entity.OrderStatusCanceled}})
+			if err != nil {
+				return fmt.Errorf("failed to normalize %s.%s: %w", collection, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+func transactionOptions() *options.TransactionOptions {
+	return options.Transaction().
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+}
+
+func (r *MongoOrderRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.inTx(ctx, func(sc mongo.SessionContext) error {
+		return fn(sc)
+	})
+}
+
+// inTx выполняет fn в транзакции сессии из ctx, а если её нет — в новой.
+// Транзиентные ошибки новой транзакции повторяются драйвером.
+func (r *MongoOrderRepository) inTx(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	if session := mongo.SessionFromContext(ctx); session != nil {
+		return fn(mongo.NewSessionContext(ctx, session))
+	}
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	}, transactionOptions())
+	return err
+}
+
+// sessionless скрывает сессию MongoDB из контекста, сохраняя отмену и дедлайн
+type sessionless struct {
+	context.Context
+}
+
+func (sessionless) Value(key interface{}) interface{} {
+	return nil
+}
+
+// nextID выдаёт следующий ID последовательности. Счётчик меняется вне транзакции,
+// поэтому ID не переиспользуются после отката, как у SERIAL в Postgres.
+func (r *MongoOrderRepository) nextID(ctx context.Context, sequence string) (int64, error) {
+	return r.nextIDs(ctx, sequence, 1)
+}
+
+// nextIDs резервирует n последовательных ID одним запросом и возвращает первый из них
+func (r *MongoOrderRepository) nextIDs(ctx context.Context, sequence string, n int64) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.db.Collection(mongoCounters).FindOneAndUpdate(sessionless{ctx},
+		bson.M{"_id": sequence},
+		bson.M{"$inc": bson.M{"seq": n}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Seq - n + 1, err
+}
+
+// mongoNow — текущее время с точностью BSON-даты
+func mongoNow() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// noRows приводит отсутствие документа к sql.ErrNoRows, как у остальных реализаций
+func noRows(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// Create сохраняет заказ в транзакции из ctx или в собственной
+func (r *MongoOrderRepository) Create(ctx context.Context, order *entity.Order) (*entity.Order, error) {
+	err := r.inTx(ctx, func(sc mongo.SessionContext) error {
+		var err error
+		if order.ID, err = r.nextID(sc, mongoOrders); err != nil {
+			return err
+		}
+		firstItemID, err := r.nextIDs(sc, mongoOrderItems, int64(len(order.Items)))
+		if err != nil {
+			return err
+		}
+
+		doc := mongoOrder{
+			ID:         order.ID,
+			UserID:     order.UserID,
+			Items:      make([]mongoOrderItem, 0, len(order.Items)),
+			TotalPrice: order.TotalPrice.Amount,
+			Currency:   order.TotalPrice.Currency,
+			Status:     order.Status,
+			CreatedAt:  order.CreatedAt,
+			UpdatedAt:  mongoNow(),
+			Version:    1,
+		}
+		for i, item := range order.Items {
+			order.Items[i].ID = firstItemID + int64(i)
+			doc.Items = append(doc.Items, mongoOrderItem{
+				ID:        order.Items[i].ID,
+				ProductID: item.ProductID,
+				Name:      item.Name,
+				Quantity:  item.Quantity,
+				Price:     item.Price.Amount,
+			})
+		}
+		if _, err := r.db.Collection(mongoOrders).InsertOne(sc, doc); err != nil {
+			return err
+		}
+		order.Version = doc.Version
+
+		return r.insertOutboxEvent(sc, entity.OrderCreatedEvent(order))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (doc *mongoOrder) toEntity() *entity.Order {
+	order := &entity.Order{
+		ID:         doc.ID,
+		UserID:     doc.UserID,
+		TotalPrice: entity.NewMoney(doc.TotalPrice, doc.Currency),
+		Status:     doc.Status,
+		CreatedAt:  doc.CreatedAt,
+		Version:    doc.version(),
+		DeletedAt:  doc.DeletedAt,
+		ArchivedAt: doc.ArchivedAt,
+	}
+	for _, item := range doc.Items {
+		order.Items = append(order.Items, entity.OrderItem{
+			ID:        item.ID,
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			Price:     entity.NewMoney(item.Price, doc.Currency),
+		})
+	}
+	return order
+}
+
+// GetOrderByID возвращает неудалённый заказ. Если в orders его нет, заказ ищется в архиве.
+func (r *MongoOrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, bson.M{"_id": orderID, "deleted_at": nil})
+}
+
+// GetOrderByIDIncludingDeleted возвращает заказ, даже если он мягко удалён
+func (r *MongoOrderRepository) GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, bson.M{"_id": orderID})
+}
+
+func (r *MongoOrderRepository) getOrderByID(ctx context.Context, filter bson.M) (*entity.Order, error) {
+	var doc mongoOrder
+	err := r.db.Collection(mongoOrders).FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = r.db.Collection(mongoOrdersArchive).FindOne(ctx, filter).Decode(&doc)
+	}
+	if err != nil {
+		return nil, orderNotFound(noRows(err))
+	}
+	return doc.toEntity(), nil
+}
+
+// Delete помечает заказ удалённым и записывает событие order.deleted. Отсутствующий
+// или уже удалённый заказ — ErrOrderNotFound, заказ, ожидающий оплаты, — ErrOrderPending.
+func (r *MongoOrderRepository) Delete(ctx context.Context, orderID int64) error {
+	return r.inTx(ctx, func(sc mongo.SessionContext) error {
+		var doc mongoOrder
+		err := r.db.Collection(mongoOrders).FindOne(sc, bson.M{"_id": orderID, "deleted_at": nil}).Decode(&doc)
+		if err != nil {
+			return orderNotFound(noRows(err))
+		}
+		if doc.Status == entity.OrderStatusPending {
+			return ErrOrderPending
+		}
+
+		now := mongoNow()
+		_, err = r.db.Collection(mongoOrders).UpdateOne(sc, bson.M{"_id": orderID},
+			bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now, "version": doc.version() + 1}})
+		if err != nil {
+			return err
+		}
+		return r.insertOutboxEvent(sc, entity.OrderDeletedEvent(doc.UserID, orderID, doc.Status, now))
+	})
+}
+
+// Restore снимает с заказа пометку удаления и записывает событие order.restored.
+// Удалённый pending-заказ остался без резервов и не восстанавливается — ErrOrderPending.
+func (r *MongoOrderRepository) Restore(ctx context.Context, orderID int64) error {
+	return r.inTx(ctx, func(sc mongo.SessionContext) error {
+		var doc mongoOrder
+		err := r.db.Collection(mongoOrders).FindOne(sc, bson.M{"_id": orderID, "deleted_at": bson.M{"$ne": nil}}).Decode(&doc)
+		if err != nil {
+			return orderNotFound(noRows(err))
+		}
+		if doc.Status == entity.OrderStatusPending {
+			return ErrOrderPending
+		}
+		now := mongoNow()
+		_, err = r.db.Collection(mongoOrders).UpdateOne(sc, bson.M{"_id": orderID}, bson.M{
+			"$set":   bson.M{"updated_at": now, "version": doc.version() + 1},
+			"$unset": bson.M{"deleted_at": ""},
+		})
+		if err != nil {
+			return err
+		}
+		return r.insertOutboxEvent(sc, entity.OrderRestoredEvent(doc.UserID, orderID, doc.Status, now))
+	})
+}
+
+// ArchiveOrders переносит завершённые заказы старше age и их историю
+// в архивные коллекции одной транзакцией
+func (r *MongoOrderRepository) ArchiveOrders(ctx context.Context, age time.Duration, limit int) ([]int64, error) {
+	var orderIDs []int64
+	err := r.inTx(ctx, func(sc mongo.SessionContext) error {
+		orderIDs = nil
+		filter := bson.M{
+			"created_at": bson.M{"$lt": time.Now().UTC().Add(-age)},
+			"status":     bson.M{"$in": entity.ArchivableStatuses},
+		}
+		cursor, err := r.db.Collection(mongoOrders).Find(sc, filter,
+			options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit)))
+		if err != nil {
+			return err
+		}
+		var docs []mongoOrder
+		if err := cursor.All(sc, &docs); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		now := mongoNow()
+		archived := make([]interface{}, 0, len(docs))
+		for i := range docs {
+			docs[i].ArchivedAt = &now
+			archived = append(archived, docs[i])
+			orderIDs = append(orderIDs, docs[i].ID)
+		}
+		if _, err := r.db.Collection(mongoOrdersArchive).InsertMany(sc, archived); err != nil {
+			return err
+		}
+
+		inOrders := bson.M{"order_id": bson.M{"$in": orderIDs}}
+		cursor, err = r.db.Collection(mongoHistory).Find(sc, inOrders)
+		if err != nil {
+			return err
+		}
+		var history []mongoStatusChange
+		if err := cursor.All(sc, &history); err != nil {
+			return err
+		}
+		if len(history) > 0 {
+			changes := make([]interface{}, 0, len(history))
+			for _, change := range history {
+				changes = append(changes, change)
+			}
+			if _, err := r.db.Collection(mongoHistoryArchive).InsertMany(sc, changes); err != nil {
+				return err
+			}
+		}
+
+		if _, err := r.db.Collection(mongoOrders).DeleteMany(sc, bson.M{"_id": bson.M{"$in": orderIDs}}); err != nil {
+			return err
+		}
+		if _, err := r.db.Collection(mongoHistory).DeleteMany(sc, inOrders); err != nil {
+			return err
+		}
+		_, err = r.db.Collection(mongoReservations).DeleteMany(sc, inOrders)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
+
+// ReserveStock резервирует quantity единиц товара за заказом, если остаток stock
+// с учётом существующих резервов это позволяет
+func (r *MongoOrderRepository) ReserveStock(ctx context.Context, orderID, productID int64, quantity int64, stock int64) error {
+	return r.inTx(ctx, func(sc mongo.SessionContext) error {
+		// Запись в документ-блокировку: вторая транзакция с тем же товаром получит WriteConflict
+		_, err := r.db.Collection(mongoStockLocks).UpdateOne(sc,
+			bson.M{"_id": productID},
+			bson.M{"$inc": bson.M{"version": 1}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+
+		reserved, err := r.sumReserved(sc, bson.M{"product_id": productID})
+		if err != nil {
+			return err
+		}
+		if stock-reserved[productID] < quantity {
+			return fmt.Errorf("%w: product %d", ErrInsufficientStock, productID)
+		}
+
+		now := mongoNow()
+		_, err = r.db.Collection(mongoReservations).InsertOne(sc, mongoReservation{
+			OrderID:   orderID,
+			ProductID: productID,
+			Quantity:  quantity,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = r.db.Collection(mongoOrders).UpdateOne(sc, bson.M{"_id": orderID}, bson.M{"$min": bson.M{"reserved_at": now}})
+		return err
+	})
+}
+
+// sumReserved возвращает суммарный резерв по товарам, подходящим под filter
+func (r *MongoOrderRepository) sumReserved(ctx context.Context, filter bson.M) (map[int64]int64, error) {
+	cursor, err := r.db.Collection(mongoReservations).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$product_id", "quantity": bson.M{"$sum": "$quantity"}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sums []struct {
+		ProductID int64 `bson:"_id"`
+		Quantity  int64 `bson:"quantity"`
+	}
+	if err := cursor.All(ctx, &sums); err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[int64]int64, len(sums))
+	for _, s := range sums {
+		reserved[s.ProductID] = s.Quantity
+	}
+	return reserved, nil
+}
+
+func (r *MongoOrderRepository) GetReservedStock(ctx context.Context, productIDs []int64) (map[int64]int64, error) {
+	if len(productIDs) == 0 {
+		return map[int64]int64{}, nil
+	}
+	return r.sumReserved(ctx, bson.M{"product_id": bson.M{"$in": productIDs}})
+}
+
+func (r *MongoOrderRepository) GetAvailableStock(ctx context.Context, productID int64) (int64, error) {
+	var product struct {
+		Stock int64 `bson:"stock"`
+	}
+	if err := r.db.Collection(mongoProducts).FindOne(ctx, bson.M{"_id": productID}).Decode(&product); err != nil {
+		return 0, noRows(err)
+	}
+
+	reserved, err := r.sumReserved(ctx, bson.M{"product_id": productID})
+	if err != nil {
+		return 0, err
+	}
+	return product.Stock - reserved[productID], nil
+}
+
+// GetExpiredOrders находит pending-заказы, зарезервированные раньше now - ttl, по reserved_at.
+// Резервы снимает смена статуса на expired; параллельная смена статуса того же заказа
+// в другой транзакции завершится WriteConflict.
+func (r *MongoOrderRepository) GetExpiredOrders(ctx context.Context, ttl time.Duration) ([]int64, error) {
+	filter := bson.M{
+		"status":      entity.OrderStatusPending,
+		"deleted_at":  nil,
+		"reserved_at": bson.M{"$lt": time.Now().UTC().Add(-ttl)},
+	}
+	cursor, err := r.db.Collection(mongoOrders).Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID int64 `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var orderIDs []int64
+	for _, doc := range docs {
+		orderIDs = append(orderIDs, doc.ID)
+	}
+	return orderIDs, nil
+}
+
+func (r *MongoOrderRepository) ReleaseReservations(ctx context.Context, orderID int64) error {
+	return r.inTx(ctx, func(sc mongo.SessionContext) error {
+		return r.releaseReservations(sc, orderID)
+	})
+}
+
+func (r *MongoOrderRepository) releaseReservations(sc mongo.SessionContext, orderID int64) error {
+	if _, err := r.db.Collection(mongoReservations).DeleteMany(sc, bson.M{"order_id": orderID}); err != nil {
+		return err
+	}
+	_, err := r.db.Collection(mongoOrders).UpdateOne(sc, bson.M{"_id": orderID}, bson.M{"$unset": bson.M{"reserved_at": ""}})
+	return err
+}
+
+// UpdateOrderStatus меняет статус заказа и записывает изменение в историю в одной транзакции
+func (r *MongoOrderRepository) UpdateOrderStatus(ctx context.Context, change *entity.StatusChange) error {
+	return r.changeStatus(ctx, change, nil)
+}
+
+func (r *MongoOrderRepository) CancelOrder(ctx context.Context, userID int64, orderID int64, expectedVersion int64) error {
+	return r.changeStatus(ctx, &entity.StatusChange{
+		OrderID:         orderID,
+		NewStatus:       entity.OrderStatusCanceled,
+		Actor:           entity.UserActor(userID),
+		ExpectedVersion: expectedVersion,
+	}, &userID)
+}
+
+// changeStatus повторяет PostgresOrderRepository.changeStatus. Обновление
+// фильтруется по прочитанной версии заказа, поэтому параллельная смена статуса
+// приводит к ErrConcurrentModification.
+func (r *MongoOrderRepository) changeStatus(ctx context.Context, change *entity.StatusChange, userID *int64) error {
+	return r.inTx(ctx, func(sc mongo.SessionContext) error {
+		filter := bson.M{"_id": change.OrderID, "deleted_at": nil}
+		if userID != nil {
+			filter["user_id"] = *userID
+		}
+
+		var order mongoOrder
+		if err := r.db.Collection(mongoOrders).FindOne(sc, filter).Decode(&order); err != nil {
+			return orderNotFound(noRows(err))
+		}
+		if change.ExpectedVersion != 0 && change.ExpectedVersion != order.version() {
+			return ErrConcurrentModification
+		}
+		current, err := entity.ParseOrderStatus(string(order.Status))
+		if err != nil {
+			return err
+		}
+		if err := current.TransitionTo(change.NewStatus); err != nil {
+			return err
+		}
+		change.OldStatus = current
+
+		// Условие на поле version: у старого документа его нет
+		var version interface{} = order.Version
+		if order.Version == 0 {
+			version = nil
+		}
+		now := mongoNow()
+		res, err := r.db.Collection(mongoOrders).UpdateOne(sc,
+			bson.M{"_id": change.OrderID, "version": version},
+			bson.M{"$set": bson.M{"status": change.NewStatus, "updated_at": now, "version": order.version() + 1}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount != 1 {
+			return ErrConcurrentModification
+		}
+
+		if current == entity.OrderStatusPending {
+			if err := r.releaseReservations(sc, change.OrderID); err != nil {
+				return err
+			}
+		}
+
+		if change.ID, err = r.nextID(sc, mongoHistory); err != nil {
+			return err
+		}
+		change.CreatedAt = now
+		_, err = r.db.Collection(mongoHistory).InsertOne(sc, mongoStatusChange{
+			ID:        change.ID,
+			OrderID:   change.OrderID,
+			OldStatus: change.OldStatus,
+			NewStatus: change.NewStatus,
+			Actor:     change.Actor,
+			Reason:    change.Reason,
+			CreatedAt: change.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return r.insertOutboxEvent(sc, entity.StatusChangedEvent(order.UserID, change))
+	})
+}
+
+// GetStatusHistory возвращает историю статусов заказа в хронологическом порядке.
+// История архивного заказа целиком лежит в архивной коллекции.
+func (r *MongoOrderRepository) GetStatusHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	var docs []mongoStatusChange
+	for _, collection := range []string{mongoHistory, mongoHistoryArchive} {
+		cursor, err := r.db.Collection(collection).Find(ctx, bson.M{"order_id": orderID},
+			options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+		if len(docs) > 0 {
+			break
+		}
+	}
+
+	history := []entity.StatusChange{}
+	for _, doc := range docs {
+		history = append(history, entity.StatusChange{
+			ID:        doc.ID,
+			OrderID:   doc.OrderID,
+			OldStatus: doc.OldStatus,
+			NewStatus: doc.NewStatus,
+			Actor:     doc.Actor,
+			Reason:    doc.Reason,
+			CreatedAt: doc.CreatedAt,
+		})
+	}
+	return history, nil
+}
+
+func (r *MongoOrderRepository) GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) ([]entity.Order, error) {
+	filter := bson.M{}
+	if query.UserID != 0 {
+		filter["user_id"] = query.UserID
+	}
+	if !query.IncludeDeleted {
+		filter["deleted_at"] = nil
+	}
+	if len(query.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": query.Statuses}
+	}
+	created := bson.M{}
+	if !query.CreatedFrom.IsZero() {
+		created["$gte"] = query.CreatedFrom
+	}
+	if !query.CreatedTo.IsZero() {
+		created["$lt"] = query.CreatedTo
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	direction, cmp := 1, "$gt"
+	if query.Sort == entity.SortDesc {
+		direction, cmp = -1, "$lt"
+	}
+	// Аналог сравнения кортежей (created_at, _id) > курсор
+	if after := query.After; after != nil {
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{cmp: after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "_id": bson.M{cmp: after.ID}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: direction}, {Key: "_id", Value: direction}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := r.db.Collection(mongoOrders).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoOrder
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var orders []entity.Order
+	for i := range docs {
+		orders = append(orders, *docs[i].toEntity())
+	}
+	return orders, nil
+}
+
+// SAGA methods
+
+func (r *MongoOrderRepository) CreateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	id, err := r.nextID(ctx, mongoSagas)
+	if err != nil {
+		return err
+	}
+	now := mongoNow()
+	doc := mongoSaga{
+		ID:         id,
+		UserID:     saga.UserID,
+		OrderID:    saga.OrderID,
+		Step:       saga.Step,
+		Status:     saga.Status,
+		PaymentURL: saga.PaymentURL,
+		Error:      saga.Error,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if _, err := r.db.Collection(mongoSagas).InsertOne(ctx, doc); err != nil {
+		return err
+	}
+	saga.ID = id
+	saga.CreatedAt, saga.UpdatedAt = now, now
+	return nil
+}
+
+func (r *MongoOrderRepository) UpdateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	var doc mongoSaga
+	err := r.db.Collection(mongoSagas).FindOneAndUpdate(ctx,
+		bson.M{"_id": saga.ID},
+		bson.M{"$set": bson.M{
+			"order_id":    saga.OrderID,
+			"step":        saga.Step,
+			"status":      saga.Status,
+			"payment_url": saga.PaymentURL,
+			"error":       saga.Error,
+			"updated_at":  mongoNow(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return noRows(err)
+	}
+	saga.CreatedAt, saga.UpdatedAt = doc.CreatedAt, doc.UpdatedAt
+	return nil
+}
+
+func (r *MongoOrderRepository) GetStaleSagas(ctx context.Context, olderThan time.Duration) ([]entity.OrderSaga, error) {
+	cursor, err := r.db.Collection(mongoSagas).Find(ctx,
+		bson.M{
+			"status":     bson.M{"$in": []entity.SagaStatus{entity.SagaStatusRunning, entity.SagaStatusCompensating}},
+			"updated_at": bson.M{"$lt": time.Now().UTC().Add(-olderThan)},
+		},
+		options.Find().SetSort(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoSaga
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var sagas []entity.OrderSaga
+	for _, doc := range docs {
+		sagas = append(sagas, entity.OrderSaga(doc))
+	}
+	return sagas, nil
+}
+
+// OUTBOX methods
+
+// insertOutboxEvent записывает событие в outbox в транзакции sc.
+// ID выдаётся вне транзакции, как и у остальных последовательностей.
+func (r *MongoOrderRepository) insertOutboxEvent(sc mongo.SessionContext, event entity.OrderEvent) error {
+	record, err := entity.NewOutboxEvent(event)
+	if err != nil {
+		return err
+	}
+	id, err := r.nextID(sc, mongoOutbox)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Collection(mongoOutbox).InsertOne(sc, mongoOutboxEvent{
+		ID:        id,
+		OrderID:   record.OrderID,
+		Type:      record.Type,
+		Payload:   string(record.Payload),
+		CreatedAt: mongoNow(),
+	})
+	return err
+}
+
+func (r *MongoOrderRepository) GetUnsentEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	cursor, err := r.db.Collection(mongoOutbox).Find(ctx, bson.M{"sent_at": nil},
+		options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoOutboxEvent
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var events []entity.OutboxEvent
+	for _, doc := range docs {
+		events = append(events, entity.OutboxEvent{
+			ID:        doc.ID,
+			OrderID:   doc.OrderID,
+			Type:      doc.Type,
+			Payload:   []byte(doc.Payload),
+			CreatedAt: doc.CreatedAt,
+		})
+	}
+	return events, nil
+}
+
+func (r *MongoOrderRepository) MarkEventsSent(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.db.Collection(mongoOutbox).UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"$set": bson.M{"sent_at": mongoNow()}},
+	)
+	return err
+}
+
+// IDEMPOTENCY methods
+
+func (r *MongoOrderRepository) ClaimIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord, ttl time.Duration) (*entity.IdempotencyRecord, error) {
+	id := mongoIdempotencyID{UserID: record.UserID, Key: record.Key}
+	keys := r.db.Collection(mongoIdempotency)
+	now := mongoNow()
+
+	// TTL-индекс удаляет записи с задержкой, поэтому истёкший ключ удаляется явно
+	if _, err := keys.DeleteOne(ctx, bson.M{"_id": id, "expires_at": bson.M{"$lt": now}}); err != nil {
+		return nil, err
+	}
+
+	_, err := keys.InsertOne(ctx, mongoIdempotencyRecord{
+		ID:          id,
+		RequestHash: record.RequestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var doc mongoIdempotencyRecord
+	err = keys.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return r.ClaimIdempotencyKey(ctx, record, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entity.IdempotencyRecord{
+		UserID:      doc.ID.UserID,
+		Key:         doc.ID.Key,
+		RequestHash: doc.RequestHash,
+		Response:    doc.Response,
+		CreatedAt:   doc.CreatedAt,
+		ExpiresAt:   doc.ExpiresAt,
+	}, nil
+}
+
+func (r *MongoOrderRepository) SaveIdempotentResponse(ctx context.Context, userID int64, key string, response []byte) error {
+	_, err := r.db.Collection(mongoIdempotency).UpdateOne(ctx,
+		bson.M{"_id": mongoIdempotencyID{UserID: userID, Key: key}},
+		bson.M{"$set": bson.M{"response": response}},
+	)
+	return err
+}
+
+func (r *MongoOrderRepository) ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	_, err := r.db.Collection(mongoIdempotency).DeleteOne(ctx, bson.M{
+		"_id":      mongoIdempotencyID{UserID: userID, Key: key},
+		"response": bson.M{"$exists": false},
+	})
+	return err
+}
@@ -2,19 +2,78 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"order_service/internal/entity"
+	"time"
 )
 
+// Transactor группирует операции репозитория в одну единицу работы
+type Transactor interface {
+	// WithinTransaction выполняет fn в транзакции: изменения фиксируются, если fn вернула nil,
+	// и откатываются в противном случае. Методы репозитория, вызванные с ctx из fn,
+	// входят в транзакцию; вложенный вызов присоединяется к внешней.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type OrderRepository interface {
-	Create(order *entity.Order) (*entity.Order, error)
-	GetOrderByID(orderID int64) (*entity.Order, error)
-	Delete(orderID int64) error
-	ReserveStock(ctx context.Context, tx *sql.Tx, orderID, productID int64, quantity int64) error
+	Transactor
+	// Create атомарно сохраняет заказ вместе с товарами
+	Create(ctx context.Context, order *entity.Order) (*entity.Order, error)
+	GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error)
+	// GetOrderByIDIncludingDeleted как GetOrderByID, но находит и мягко удалённый заказ
+	GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error)
+	// Delete помечает заказ удалённым; товары и история сохраняются. Статус не меняется,
+	// поэтому удаление публикуется событием order.deleted, а не записью в истории.
+	// Если неудалённого заказа нет, возвращает ErrOrderNotFound, а если заказ ещё
+	// ждёт оплаты — ErrOrderPending.
+	Delete(ctx context.Context, orderID int64) error
+	// Restore снимает пометку удаления и публикует order.restored; если удалённого
+	// заказа нет, возвращает ErrOrderNotFound. Удалённый pending-заказ без резервов
+	// не восстанавливается: возвращается ErrOrderPending.
+	Restore(ctx context.Context, orderID int64) error
+	// ArchiveOrders переносит в архив до limit завершённых заказов старше age и возвращает их ID.
+	// Переносятся только заказы в entity.ArchivableStatuses.
+	ArchiveOrders(ctx context.Context, age time.Duration, limit int) ([]int64, error)
+	ReserveStock(ctx context.Context, orderID, productID int64, quantity int64, stock int64) error
+	GetReservedStock(ctx context.Context, productIDs []int64) (map[int64]int64, error)
 	GetAvailableStock(ctx context.Context, productID int64) (int64, error)
-	BeginTransaction() (*sql.Tx, error)
-	ClearExpiredReservations(ctx context.Context) ([]int64, error)
-	UpdateOrder(order *entity.Order) error
-	GetOrdersByUserID(userID int64) ([]entity.Order, error)
-	CancelOrder(userID int64, orderID int64) error
+	// GetExpiredOrders возвращает ID неудалённых pending-заказов, резерв которых старше ttl.
+	// В транзакции строки заказов блокируются до её конца, чтобы перевести их в expired.
+	GetExpiredOrders(ctx context.Context, ttl time.Duration) ([]int64, error)
+	UpdateOrderStatus(ctx context.Context, change *entity.StatusChange) error
+	GetStatusHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error)
+	// GetOrdersByUserID возвращает до query.Limit заказов пользователя после курсора query.After.
+	// При query.UserID == 0 выбираются заказы всех пользователей, удалённые — только с query.IncludeDeleted.
+	GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) ([]entity.Order, error)
+	// CancelOrder отменяет заказ пользователя; expectedVersion 0 — без проверки версии
+	CancelOrder(ctx context.Context, userID int64, orderID int64, expectedVersion int64) error
+	ReleaseReservations(ctx context.Context, orderID int64) error
+	SagaRepository
+	OutboxRepository
+	IdempotencyRepository
+}
+
+// SagaRepository хранит состояние саг создания заказа
+type SagaRepository interface {
+	CreateSaga(ctx context.Context, saga *entity.OrderSaga) error
+	UpdateSaga(ctx context.Context, saga *entity.OrderSaga) error
+	// GetStaleSagas возвращает незавершённые саги, не обновлявшиеся дольше olderThan
+	GetStaleSagas(ctx context.Context, olderThan time.Duration) ([]entity.OrderSaga, error)
+}
+
+// OutboxRepository читает события outbox для публикации. Записываются они
+// самим репозиторием в транзакциях, меняющих заказ.
+type OutboxRepository interface {
+	// GetUnsentEvents возвращает до limit неопубликованных событий. В транзакции
+	// события захватываются до её завершения, и другие экземпляры сервиса их пропускают.
+	GetUnsentEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error)
+	MarkEventsSent(ctx context.Context, ids []int64) error
+}
+
+// IdempotencyRepository хранит ключи Idempotency-Key и ответы на первые запросы
+type IdempotencyRepository interface {
+	// ClaimIdempotencyKey занимает ключ на ttl. Если ключ уже занят и не истёк, возвращает существующую запись.
+	ClaimIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord, ttl time.Duration) (*entity.IdempotencyRecord, error)
+	SaveIdempotentResponse(ctx context.Context, userID int64, key string, response []byte) error
+	// ReleaseIdempotencyKey освобождает ключ, если запрос завершился ошибкой
+	ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error
 }
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"order_service/internal/entity"
+	"time"
+)
+
+// ClaimIdempotencyKey занимает ключ пользователя на ttl. Истёкший ключ занимается заново.
+func (r *PostgresOrderRepository) ClaimIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord, ttl time.Duration) (*entity.IdempotencyRecord, error) {
+	// Попутно удаляем истёкшие ключи, чтобы таблица не росла бесконечно
+	if _, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < NOW()"); err != nil {
+		return nil, err
+	}
+
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')
+		ON CONFLICT (user_id, key) DO NOTHING`,
+		record.UserID, record.Key, record.RequestHash, ttl.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 1 {
+		return nil, nil
+	}
+
+	existing := entity.IdempotencyRecord{UserID: record.UserID, Key: record.Key}
+	var response []byte
+	err = r.conn(ctx).QueryRowContext(ctx,
+		`SELECT request_hash, response, created_at, expires_at
+		FROM idempotency_keys WHERE user_id = $1 AND key = $2`,
+		record.UserID, record.Key,
+	).Scan(&existing.RequestHash, &response, &existing.CreatedAt, &existing.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Ключ освободили между вставкой и чтением — пробуем ещё раз
+		return r.ClaimIdempotencyKey(ctx, record, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	existing.Response = response
+	return &existing, nil
+}
+
+// SaveIdempotentResponse сохраняет ответ на первый запрос с ключом
+func (r *PostgresOrderRepository) SaveIdempotentResponse(ctx context.Context, userID int64, key string, response []byte) error {
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"UPDATE idempotency_keys SET response = $3 WHERE user_id = $1 AND key = $2",
+		userID, key, response,
+	)
+	return err
+}
+
+// ReleaseIdempotencyKey удаляет ключ без сохранённого ответа
+func (r *PostgresOrderRepository) ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND response IS NULL",
+		userID, key,
+	)
+	return err
+}
@@ -3,83 +3,317 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"order_service/internal/domainerr"
 	"order_service/internal/entity"
+	"order_service/internal/migrations"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrInsufficientStock возвращается, если резерв превышает доступный остаток
+var ErrInsufficientStock = domainerr.New(domainerr.InsufficientStock, "insufficient stock")
+
+// ErrConcurrentModification возвращается, если заказ изменился после того,
+// как инициатор прочитал его версию
+var ErrConcurrentModification = domainerr.New(domainerr.Conflict, "order was modified concurrently")
+
+// ErrOrderNotFound возвращается, если заказа нет или он удалён. Для неё верно
+// и errors.Is(err, sql.ErrNoRows), как для остальных отсутствующих записей.
+var ErrOrderNotFound = domainerr.Wrap(domainerr.NotFound, sql.ErrNoRows, "order not found")
+
+// ErrOrderPending возвращается при удалении или восстановлении заказа, который ждёт оплаты:
+// у него действуют резервы и ссылка на оплату, поэтому сначала заказ нужно отменить
+var ErrOrderPending = domainerr.New(domainerr.Conflict, "pending order must be canceled first")
+
+// orderNotFound заменяет отсутствие строки заказа на ErrOrderNotFound
+func orderNotFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrOrderNotFound
+	}
+	return err
+}
+
+// PostgresOrderRepository — реализация OrderRepository на Postgres.
+//
+// Чтения заказов и истории статусов (GetOrderByID, GetOrdersByUserID,
+// GetStatusHistory) могут идти на реплики, заданные WithReadReplicas.
+// Остальные методы, включая проверки остатков, всегда работают с основной базой.
 type PostgresOrderRepository struct {
-	db *sql.DB
+	sqlTransactor
+	replicas *ReplicaSet
 }
 
-func NewPostgresOrderRepository(db *sql.DB) OrderRepository {
-	return &PostgresOrderRepository{db: db}
+// PostgresOption настраивает PostgresOrderRepository
+type PostgresOption func(*PostgresOrderRepository)
+
+// WithReadReplicas направляет чтения заказов на реплики
+func WithReadReplicas(replicas *ReplicaSet) PostgresOption {
+	return func(r *PostgresOrderRepository) {
+		r.replicas = replicas
+	}
 }
 
-func (r *PostgresOrderRepository) Create(order *entity.Order) (*entity.Order, error) {
-	// Создаем заказ и получаем его ID
-	err := r.db.QueryRow(
-		"INSERT INTO orders (user_id, total_price, status, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
-		order.UserID, order.TotalPrice, order.Status, order.CreatedAt,
-	).Scan(&order.ID)
-	if err != nil {
-		return nil, err
+func NewPostgresOrderRepository(db *sql.DB, opts ...PostgresOption) OrderRepository {
+	r := &PostgresOrderRepository{sqlTransactor: sqlTransactor{db: db}}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
+}
 
-	// Вставляем товары в заказ
-	stmt, err := r.db.Prepare("INSERT INTO order_items (order_id, product_id, name, quantity, price) VALUES ($1, $2, $3, $4, $5)")
-	if err != nil {
-		return nil, err
+// read выполняет чтение fn на реплике, если запрос может читать с неё, иначе
+// на основной базе. Если реплика вернула ошибку, отличную от sql.ErrNoRows,
+// она исключается из выбора, а чтение повторяется на основной базе.
+func (r *PostgresOrderRepository) read(ctx context.Context, fn func(q sqlConn) error) error {
+	rep := r.replicas.pick(ctx)
+	if rep == nil {
+		return fn(r.conn(ctx))
+	}
+
+	err := fn(rep.db)
+	if err == nil || errors.Is(err, sql.ErrNoRows) || ctx.Err() != nil {
+		return err
 	}
-	defer stmt.Close()
+	r.replicas.markDown(rep, err)
+	return fn(r.conn(ctx))
+}
 
-	for _, item := range order.Items {
-		_, err := stmt.Exec(order.ID, item.ProductID, item.Name, item.Quantity, item.Price)
+// Create сохраняет заказ и его товары в одной транзакции: в транзакции из ctx или в собственной
+func (r *PostgresOrderRepository) Create(ctx context.Context, order *entity.Order) (*entity.Order, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		// Создаем заказ и получаем его ID
+		err := tx.QueryRowContext(ctx,
+			"INSERT INTO orders (user_id, total_price, currency, status, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, version",
+			order.UserID, order.TotalPrice, order.TotalPrice.Currency, order.Status, order.CreatedAt,
+		).Scan(&order.ID, &order.Version)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		// Вставляем товары в заказ
+		stmt, err := tx.PrepareContext(ctx, "INSERT INTO order_items (order_id, product_id, name, quantity, price) VALUES ($1, $2, $3, $4, $5) RETURNING id")
+		if err != nil {
+			return err
 		}
+		defer stmt.Close()
+
+		for i, item := range order.Items {
+			if err := stmt.QueryRowContext(ctx, order.ID, item.ProductID, item.Name, item.Quantity, item.Price).Scan(&order.Items[i].ID); err != nil {
+				return err
+			}
+		}
+
+		// Событие публикуется, только если транзакция заказа будет зафиксирована
+		return insertOutboxEvent(ctx, tx, entity.OrderCreatedEvent(order))
+	})
+	if err != nil {
+		return nil, err
 	}
+	return order, nil
+}
+
+// GetOrderByID возвращает неудалённый заказ. Если в orders его нет, заказ ищется в архиве.
+func (r *PostgresOrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, orderID, false)
+}
 
+// GetOrderByIDIncludingDeleted возвращает заказ, даже если он мягко удалён
+func (r *PostgresOrderRepository) GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, orderID, true)
+}
+
+func (r *PostgresOrderRepository) getOrderByID(ctx context.Context, orderID int64, includeDeleted bool) (*entity.Order, error) {
+	var order *entity.Order
+	err := r.read(ctx, func(q sqlConn) error {
+		var err error
+		order, err = r.getOrder(ctx, q, liveTables, orderID, includeDeleted)
+		if errors.Is(err, sql.ErrNoRows) {
+			order, err = r.getOrder(ctx, q, archiveTables, orderID, includeDeleted)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, orderNotFound(err)
+	}
 	return order, nil
 }
 
-func (r *PostgresOrderRepository) GetOrderByID(orderID int64) (*entity.Order, error) {
+func (r *PostgresOrderRepository) getOrder(ctx context.Context, q sqlConn, tables orderTables, orderID int64, includeDeleted bool) (*entity.Order, error) {
 	var order entity.Order
-	err := r.db.QueryRow("SELECT id, user_id, total_price, status, created_at FROM orders WHERE id=$1", orderID).
-		Scan(&order.ID, &order.UserID, &order.TotalPrice, &order.Status, &order.CreatedAt)
+	err := q.QueryRowContext(ctx, tables.selectOrderSQL("$1", includeDeleted), orderID).
+		Scan(tables.scanFields(&order)...)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := r.db.Query("SELECT product_id, quantity, price FROM order_items WHERE order_id=$1", orderID)
-	if err != nil {
+	orders := []entity.Order{order}
+	if err := r.loadItems(ctx, q, tables.items, orders); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return &orders[0], nil
+}
 
-	for rows.Next() {
-		var item entity.OrderItem
-		if err := rows.Scan(&item.ProductID, &item.Quantity, &item.Price); err != nil {
-			return nil, err
+// Delete помечает заказ удалённым и записывает событие order.deleted. Товары и история
+// остаются для учёта. Отсутствующий или уже удалённый заказ — ErrOrderNotFound,
+// заказ, ожидающий оплаты, — ErrOrderPending. Резервов у остальных заказов нет:
+// их снимает смена статуса.
+func (r *PostgresOrderRepository) Delete(ctx context.Context, orderID int64) error {
+	return orderNotFound(r.inTx(ctx, func(tx *sql.Tx) error {
+		userID, status, err := r.lockOrder(ctx, tx, orderID, "deleted_at IS NULL")
+		if err != nil {
+			return err
+		}
+		if status == entity.OrderStatusPending {
+			return ErrOrderPending
 		}
-		order.Items = append(order.Items, item)
-	}
 
-	return &order, nil
+		var deletedAt time.Time
+		err = tx.QueryRowContext(ctx,
+			"UPDATE orders SET deleted_at = NOW(), version = version + 1, updated_at = NOW() WHERE id = $1 RETURNING deleted_at",
+			orderID).Scan(&deletedAt)
+		if err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, entity.OrderDeletedEvent(userID, orderID, status, deletedAt.UTC()))
+	}))
 }
 
-func (r *PostgresOrderRepository) Delete(orderID int64) error {
-	_, err := r.db.Exec("DELETE FROM orders WHERE id = $1", orderID)
-	return err
+// Restore снимает с заказа пометку удаления и записывает событие order.restored.
+// Pending-заказ, удалённый до запрета таких удалений, остался без резервов — ErrOrderPending.
+func (r *PostgresOrderRepository) Restore(ctx context.Context, orderID int64) error {
+	return orderNotFound(r.inTx(ctx, func(tx *sql.Tx) error {
+		userID, status, err := r.lockOrder(ctx, tx, orderID, "deleted_at IS NOT NULL")
+		if err != nil {
+			return err
+		}
+		if status == entity.OrderStatusPending {
+			return ErrOrderPending
+		}
+
+		var restoredAt time.Time
+		err = tx.QueryRowContext(ctx,
+			"UPDATE orders SET deleted_at = NULL, version = version + 1, updated_at = NOW() WHERE id = $1 RETURNING updated_at",
+			orderID).Scan(&restoredAt)
+		if err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, entity.OrderRestoredEvent(userID, orderID, status, restoredAt.UTC()))
+	}))
+}
+
+// lockOrder читает владельца и статус заказа, подходящего под condition, и блокирует
+// его строку до конца транзакции
+func (r *PostgresOrderRepository) lockOrder(ctx context.Context, tx *sql.Tx, orderID int64, condition string) (int64, entity.OrderStatus, error) {
+	var userID int64
+	var raw string
+	err := tx.QueryRowContext(ctx, "SELECT user_id, status FROM orders WHERE id = $1 AND "+condition+" FOR UPDATE", orderID).
+		Scan(&userID, &raw)
+	if err != nil {
+		return 0, "", err
+	}
+	status, err := entity.ParseOrderStatus(raw)
+	return userID, status, err
+}
+
+// ArchiveOrders переносит завершённые заказы старше age вместе с товарами и историей
+// в архивные таблицы. Параллельные вызовы пропускают заказы, которые уже переносит другой.
+func (r *PostgresOrderRepository) ArchiveOrders(ctx context.Context, age time.Duration, limit int) ([]int64, error) {
+	var orderIDs []int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		orderIDs = nil
+		rows, err := tx.QueryContext(ctx,
+			`SELECT id FROM orders WHERE created_at < NOW() - $1 * INTERVAL '1 second' AND status = ANY($2)
+			ORDER BY id LIMIT $3 FOR UPDATE SKIP LOCKED`,
+			age.Seconds(), pq.Array(statusStrings(entity.ArchivableStatuses)), limit,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var orderID int64
+			if err := rows.Scan(&orderID); err != nil {
+				return err
+			}
+			orderIDs = append(orderIDs, orderID)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+		if len(orderIDs) == 0 {
+			return nil
+		}
+
+		for _, query := range archiveOrdersSQL("= ANY($1)") {
+			if _, err := tx.ExecContext(ctx, query, pq.Array(orderIDs)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
 }
 
 // STOCK methods
-func (r *PostgresOrderRepository) BeginTransaction() (*sql.Tx, error) {
-	return r.db.Begin()
+
+// advisoryObject сворачивает ID в объект advisory-блокировки (int4). Совпадение
+// объектов разных ID лишь сериализует их резервы, но не нарушает корректность.
+func advisoryObject(id int64) int32 {
+	return int32(id ^ id>>32)
 }
 
-func (r *PostgresOrderRepository) ReserveStock(ctx context.Context, tx *sql.Tx, orderID, productID int64, quantity int64) error {
-	_, err := tx.ExecContext(ctx, "INSERT INTO reserved_stock (order_id, product_id, quantity) VALUES ($1, $2, $3)", orderID, productID, quantity)
-	return err
+// ReserveStock резервирует quantity единиц товара за заказом, если с учётом
+// уже существующих резервов остаток stock это позволяет. Резервы одного товара
+// сериализуются advisory-блокировкой класса migrations.AdvisoryClassStock до конца
+// транзакции, поэтому два параллельных заказа не могут забрать одну и ту же последнюю единицу.
+func (r *PostgresOrderRepository) ReserveStock(ctx context.Context, orderID, productID int64, quantity int64, stock int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, $2)", migrations.AdvisoryClassStock, advisoryObject(productID)); err != nil {
+			return err
+		}
+
+		var reserved int64
+		err := tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(quantity), 0) FROM reserved_stock WHERE product_id = $1", productID).
+			Scan(&reserved)
+		if err != nil {
+			return err
+		}
+		if stock-reserved < quantity {
+			return fmt.Errorf("%w: product %d", ErrInsufficientStock, productID)
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO reserved_stock (order_id, product_id, quantity) VALUES ($1, $2, $3)", orderID, productID, quantity)
+		return err
+	})
+}
+
+// GetReservedStock возвращает суммарный резерв по каждому из товаров
+func (r *PostgresOrderRepository) GetReservedStock(ctx context.Context, productIDs []int64) (map[int64]int64, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		"SELECT product_id, SUM(quantity) FROM reserved_stock WHERE product_id = ANY($1) GROUP BY product_id",
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reserved := make(map[int64]int64, len(productIDs))
+	for rows.Next() {
+		var productID, quantity int64
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		reserved[productID] = quantity
+	}
+	return reserved, rows.Err()
 }
 
 func (r *PostgresOrderRepository) GetAvailableStock(ctx context.Context, productID int64) (int64, error) {
@@ -88,14 +322,24 @@ func (r *PostgresOrderRepository) GetAvailableStock(ctx context.Context, product
 		SELECT stock - COALESCE((SELECT SUM(quantity) FROM reserved_stock WHERE product_id = $1), 0) 
 		FROM products WHERE id = $1
 	`
-	err := r.db.QueryRowContext(ctx, query, productID).Scan(&availableStock)
+	err := r.conn(ctx).QueryRowContext(ctx, query, productID).Scan(&availableStock)
 	return availableStock, err
 }
 
-func (r *PostgresOrderRepository) ClearExpiredReservations(ctx context.Context) ([]int64, error) {
-	rows, err := r.db.QueryContext(ctx, `DELETE FROM reserved_stock WHERE created_at < NOW() - INTERVAL '5 minutes' RETURNING order_id`)
+// GetExpiredOrders возвращает ID pending-заказов с резервами старше ttl. Резервы не
+// удаляются: их снимает смена статуса на expired. Строки заказов блокируются до конца
+// транзакции из ctx, а заказы, которые уже обрабатывает другой воркер, пропускаются.
+func (r *PostgresOrderRepository) GetExpiredOrders(ctx context.Context, ttl time.Duration) ([]int64, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT o.id FROM orders o
+		WHERE o.status = $2 AND o.deleted_at IS NULL AND EXISTS (
+			SELECT 1 FROM reserved_stock r WHERE r.order_id = o.id AND r.created_at < NOW() - $1 * INTERVAL '1 second'
+		)
+		ORDER BY o.id FOR UPDATE SKIP LOCKED`,
+		ttl.Seconds(), entity.OrderStatusPending,
+	)
 	if err != nil {
-		return []int64{}, err // Возвращаем ПУСТОЙ массив вместо nil
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -103,25 +347,123 @@ func (r *PostgresOrderRepository) ClearExpiredReservations(ctx context.Context)
 	for rows.Next() {
 		var orderID int64
 		if err := rows.Scan(&orderID); err != nil {
-			return []int64{}, err // Ошибка чтения → пустой массив
+			return nil, err
 		}
 		orderIDs = append(orderIDs, orderID)
 	}
-	return orderIDs, nil
+	return orderIDs, rows.Err()
+}
+
+// UpdateOrderStatus меняет статус заказа и записывает изменение в историю
+// в одной транзакции. Переход проверяется по графу entity.OrderStatus.
+func (r *PostgresOrderRepository) UpdateOrderStatus(ctx context.Context, change *entity.StatusChange) error {
+	return r.changeStatus(ctx, change, nil)
+}
+
+// changeStatus переводит заказ в статус change.NewStatus, блокируя строку на время проверки.
+// Если userID не nil, заказ должен принадлежать этому пользователю. Если задан
+// change.ExpectedVersion, а версия заказа уже другая, возвращает ErrConcurrentModification.
+// Заполняет change.OldStatus, change.ID и change.CreatedAt.
+func (r *PostgresOrderRepository) changeStatus(ctx context.Context, change *entity.StatusChange, userID *int64) error {
+	return orderNotFound(r.inTx(ctx, func(tx *sql.Tx) error {
+		query := "SELECT status, user_id, version FROM orders WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+		args := []interface{}{change.OrderID}
+		if userID != nil {
+			query = "SELECT status, user_id, version FROM orders WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE"
+			args = append(args, *userID)
+		}
+
+		var raw string
+		var ownerID, version int64
+		if err := tx.QueryRowContext(ctx, query, args...).Scan(&raw, &ownerID, &version); err != nil {
+			return err
+		}
+		if change.ExpectedVersion != 0 && change.ExpectedVersion != version {
+			return ErrConcurrentModification
+		}
+		current, err := entity.ParseOrderStatus(raw)
+		if err != nil {
+			return err
+		}
+		if err := current.TransitionTo(change.NewStatus); err != nil {
+			return err
+		}
+		change.OldStatus = current
+
+		res, err := tx.ExecContext(ctx,
+			"UPDATE orders SET status = $1, version = version + 1, updated_at = NOW() WHERE id = $2 AND version = $3",
+			change.NewStatus, change.OrderID, version)
+		if err := expectOneRow(res, err); err != nil {
+			return err
+		}
+
+		// Резерв нужен только неоплаченному заказу: при оплате остаток списывается
+		// в Product Service, а при отмене, истечении или ошибке оплаты товар освобождается
+		if current == entity.OrderStatusPending {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM reserved_stock WHERE order_id = $1", change.OrderID); err != nil {
+				return err
+			}
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO order_status_history (order_id, old_status, new_status, actor, reason)
+			VALUES ($1, $2, $3, $4, NULLIF($5, '')) RETURNING id, created_at`,
+			change.OrderID, change.OldStatus, change.NewStatus, change.Actor, change.Reason,
+		).Scan(&change.ID, &change.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if err := insertOutboxEvent(ctx, tx, entity.StatusChangedEvent(ownerID, change)); err != nil {
+			return err
+		}
+
+		return nil
+	}))
+}
+
+// GetStatusHistory возвращает историю статусов заказа, в том числе архивного, в хронологическом порядке
+func (r *PostgresOrderRepository) GetStatusHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	var history []entity.StatusChange
+	err := r.read(ctx, func(q sqlConn) error {
+		rows, err := q.QueryContext(ctx, statusHistorySQL("$1"), orderID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		history = []entity.StatusChange{}
+		for rows.Next() {
+			var c entity.StatusChange
+			if err := rows.Scan(&c.ID, &c.OrderID, &c.OldStatus, &c.NewStatus, &c.Actor, &c.Reason, &c.CreatedAt); err != nil {
+				return err
+			}
+			history = append(history, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
 }
 
-// UpdateOrder обновляет заказ в базе данных
-func (r *PostgresOrderRepository) UpdateOrder(order *entity.Order) error {
-	_, err := r.db.Exec("UPDATE orders SET status = $1 WHERE id = $2", order.Status, order.ID)
+func (r *PostgresOrderRepository) GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) ([]entity.Order, error) {
+	var orders []entity.Order
+	err := r.read(ctx, func(q sqlConn) error {
+		var err error
+		orders, err = r.listOrders(ctx, q, query)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("ошибка при обновлении заказа: %w", err)
+		return nil, err
 	}
-	return nil
+	return orders, nil
 }
 
-func (r *PostgresOrderRepository) GetOrdersByUserID(userID int64) ([]entity.Order, error) {
-	query := `SELECT id, user_id, total_price, status, created_at FROM orders WHERE user_id = $1`
-	rows, err := r.db.Query(query, userID)
+func (r *PostgresOrderRepository) listOrders(ctx context.Context, q sqlConn, query entity.OrderQuery) ([]entity.Order, error) {
+	where, args := orderQuerySQL(query, func(n int) string { return fmt.Sprintf("$%d", n) })
+	rows, err := q.QueryContext(ctx, "SELECT "+orderColumns+" FROM orders"+where, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -130,39 +472,41 @@ func (r *PostgresOrderRepository) GetOrdersByUserID(userID int64) ([]entity.Orde
 	var orders []entity.Order
 	for rows.Next() {
 		var order entity.Order
-		if err := rows.Scan(&order.ID, &order.UserID, &order.TotalPrice, &order.Status, &order.CreatedAt); err != nil {
-			return nil, err
-		}
-		order.Items, err = r.getProductsByOrderID(order.ID)
-		if err != nil {
+		if err := rows.Scan(orderFields(&order)...); err != nil {
 			return nil, err
 		}
 		orders = append(orders, order)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
+	if err := r.loadItems(ctx, q, liveTables.items, orders); err != nil {
+		return nil, err
+	}
 	return orders, nil
 }
 
-func (r *PostgresOrderRepository) getProductsByOrderID(orderID int64) ([]entity.OrderItem, error) {
-	rows, err := r.db.Query(`SELECT product_id, name, quantity FROM order_items WHERE order_id = $1`, orderID)
-	if err != nil {
-		return nil, err
+// loadItems загружает товары всех заказов одним запросом из таблицы table
+func (r *PostgresOrderRepository) loadItems(ctx context.Context, q sqlConn, table string, orders []entity.Order) error {
+	if len(orders) == 0 {
+		return nil
 	}
-	defer rows.Close()
-
-	var products []entity.OrderItem
-	for rows.Next() {
-		var p entity.OrderItem
-		if err := rows.Scan(&p.ProductID, &p.Name, &p.Quantity); err != nil {
-			return nil, err
-		}
-		products = append(products, p)
+	rows, err := q.QueryContext(ctx,
+		"SELECT "+orderItemColumns+" FROM "+table+" WHERE order_id = ANY($1) ORDER BY order_id, id",
+		pq.Array(orderIDs(orders)))
+	if err != nil {
+		return err
 	}
-
-	return products, nil
+	return scanOrderItems(rows, orders)
 }
 
-func (r *PostgresOrderRepository) CancelOrder(userID int64, orderID int64) error {
-	_, err := r.db.Exec("UPDATE orders SET status = 'cancelled' WHERE id = $1 AND user_id = $2", orderID, userID)
-	return err
+func (r *PostgresOrderRepository) CancelOrder(ctx context.Context, userID int64, orderID int64, expectedVersion int64) error {
+	return r.changeStatus(ctx, &entity.StatusChange{
+		OrderID:         orderID,
+		NewStatus:       entity.OrderStatusCanceled,
+		Actor:           entity.UserActor(userID),
+		ExpectedVersion: expectedVersion,
+	}, &userID)
 }
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"order_service/internal/entity"
+
+	"github.com/lib/pq"
+)
+
+// insertOutboxEvent записывает событие в outbox в рамках транзакции изменения заказа
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, event entity.OrderEvent) error {
+	record, err := entity.NewOutboxEvent(event)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO outbox (order_id, event_type, payload) VALUES ($1, $2, $3)",
+		record.OrderID, record.Type, []byte(record.Payload),
+	)
+	return err
+}
+
+// GetUnsentEvents возвращает до limit неопубликованных событий в порядке записи.
+// Строки блокируются до конца транзакции, а занятые другой транзакцией пропускаются,
+// поэтому несколько экземпляров сервиса не публикуют одни и те же события.
+func (r *PostgresOrderRepository) GetUnsentEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT id, order_id, event_type, payload, created_at
+		FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT $1
+		FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []entity.OutboxEvent
+	for rows.Next() {
+		var event entity.OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.OrderID, &event.Type, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// MarkEventsSent отмечает события опубликованными
+func (r *PostgresOrderRepository) MarkEventsSent(ctx context.Context, ids []int64) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE outbox SET sent_at = NOW() WHERE id = ANY($1)", pq.Array(ids))
+	return err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"order_service/internal/entity"
+	"time"
+)
+
+// ReleaseReservations снимает все резервы заказа
+func (r *PostgresOrderRepository) ReleaseReservations(ctx context.Context, orderID int64) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM reserved_stock WHERE order_id = $1", orderID)
+	return err
+}
+
+// CreateSaga сохраняет новую сагу и заполняет ID и время создания
+func (r *PostgresOrderRepository) CreateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	return r.conn(ctx).QueryRowContext(ctx,
+		`INSERT INTO order_sagas (user_id, order_id, step, status, payment_url, error)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`,
+		saga.UserID, nullableID(saga.OrderID), saga.Step, saga.Status, saga.PaymentURL, saga.Error,
+	).Scan(&saga.ID, &saga.CreatedAt, &saga.UpdatedAt)
+}
+
+// UpdateSaga сохраняет текущий шаг и статус саги
+func (r *PostgresOrderRepository) UpdateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	err := r.conn(ctx).QueryRowContext(ctx,
+		`UPDATE order_sagas
+		SET order_id = $2, step = $3, status = $4, payment_url = $5, error = $6, updated_at = NOW()
+		WHERE id = $1 RETURNING updated_at`,
+		saga.ID, nullableID(saga.OrderID), saga.Step, saga.Status, saga.PaymentURL, saga.Error,
+	).Scan(&saga.UpdatedAt)
+	return err
+}
+
+// GetStaleSagas возвращает саги в статусах running и compensating, не обновлявшиеся дольше olderThan
+func (r *PostgresOrderRepository) GetStaleSagas(ctx context.Context, olderThan time.Duration) ([]entity.OrderSaga, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT id, user_id, order_id, step, status, payment_url, error, created_at, updated_at
+		FROM order_sagas
+		WHERE status IN ($1, $2) AND updated_at < NOW() - $3 * INTERVAL '1 second'
+		ORDER BY id`,
+		entity.SagaStatusRunning, entity.SagaStatusCompensating, olderThan.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sagas []entity.OrderSaga
+	for rows.Next() {
+		var saga entity.OrderSaga
+		var orderID sql.NullInt64
+		if err := rows.Scan(&saga.ID, &saga.UserID, &orderID, &saga.Step, &saga.Status,
+			&saga.PaymentURL, &saga.Error, &saga.CreatedAt, &saga.UpdatedAt); err != nil {
+			return nil, err
+		}
+		saga.OrderID = orderID.Int64
+		sagas = append(sagas, saga)
+	}
+	return sagas, rows.Err()
+}
+
+// nullableID превращает нулевой ID в NULL
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order_service/internal/entity"
+	"order_service/internal/migrations"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// countingConnector открывает соединения драйвера и считает запросы к базе
+type countingConnector struct {
+	dsn     string
+	driver  driver.Driver
+	queries atomic.Int64
+}
+
+func (c *countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, queries: &c.queries}, nil
+}
+
+func (c *countingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// countingConn считает запросы, выполненные напрямую или через подготовленные выражения
+type countingConn struct {
+	driver.Conn
+	queries *atomic.Int64
+}
+
+func (c *countingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *countingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.queries.Add(1)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.queries.Add(1)
+	}
+	return rows, err
+}
+
+func (c *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	res, err := e.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.queries.Add(1)
+	}
+	return res, err
+}
+
+func (c *countingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// openCountingSQLite открывает чистую базу SQLite со схемой и счётчиком запросов
+func openCountingSQLite(tb testing.TB) (OrderRepository, *countingConnector) {
+	connector := &countingConnector{
+		dsn:    sqliteDSN(filepath.Join(tb.TempDir(), "orders.db")),
+		driver: &sqlite3.SQLiteDriver{},
+	}
+	db := sql.OpenDB(connector)
+	tb.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db, string(SQLite))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+	return NewSQLiteOrderRepository(db), connector
+}
+
+// seedOrders создаёт n заказов пользователя 7 по два товара в каждом
+func seedOrders(tb testing.TB, repo OrderRepository, n int) {
+	err := repo.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		for i := 0; i < n; i++ {
+			_, err := repo.Create(ctx, &entity.Order{
+				UserID: 7,
+				Items: []entity.OrderItem{
+					{ProductID: 1, Name: "Product 1", Quantity: 2, Price: kzt(5000)},
+					{ProductID: 2, Name: "Product 2", Quantity: 1, Price: kzt(10000)},
+				},
+				TotalPrice: kzt(20000),
+				Status:     entity.OrderStatusPending,
+				CreatedAt:  time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// countListQueries возвращает число запросов, которое делает одна загрузка страницы из n заказов
+func countListQueries(tb testing.TB, repo OrderRepository, connector *countingConnector, n int) int64 {
+	connector.queries.Store(0)
+	orders, err := repo.GetOrdersByUserID(context.Background(), entity.OrderQuery{UserID: 7, Limit: n})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(orders) != n || len(orders[n-1].Items) != 2 {
+		tb.Fatalf("expected %d orders with 2 items each, got %d", n, len(orders))
+	}
+	return connector.queries.Load()
+}
+
+func TestSQLiteOrderRepository_ListQueryCount(t *testing.T) {
+	repo, connector := openCountingSQLite(t)
+	seedOrders(t, repo, 50)
+
+	// Заказы и их товары загружаются двумя запросами независимо от размера страницы
+	for _, n := range []int{1, 50} {
+		if queries := countListQueries(t, repo, connector, n); queries != 2 {
+			t.Errorf("page of %d orders: expected 2 queries, got %d", n, queries)
+		}
+	}
+}
+
+func BenchmarkGetOrdersByUserID(b *testing.B) {
+	open := map[string]func(b *testing.B) (OrderRepository, *countingConnector){
+		"sqlite": func(b *testing.B) (OrderRepository, *countingConnector) {
+			return openCountingSQLite(b)
+		},
+	}
+	// TEST_POSTGRES_DSN=postgres://... go test -run '^$' -bench GetOrdersByUserID ./internal/repository
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		open["postgres"] = func(b *testing.B) (OrderRepository, *countingConnector) {
+			connector := &countingConnector{dsn: dsn, driver: &pq.Driver{}}
+			db := sql.OpenDB(connector)
+			b.Cleanup(func() { db.Close() })
+			if _, err := db.Exec(`TRUNCATE orders, order_items RESTART IDENTITY CASCADE`); err != nil {
+				b.Fatal(err)
+			}
+			return NewPostgresOrderRepository(db), connector
+		}
+	}
+
+	for name, openRepo := range open {
+		for _, n := range []int{10, 100, 1000} {
+			b.Run(fmt.Sprintf("%s/orders=%d", name, n), func(b *testing.B) {
+				repo, connector := openRepo(b)
+				seedOrders(b, repo, n)
+
+				var queries int64
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					queries += countListQueries(b, repo, connector, n)
+				}
+				b.ReportMetric(float64(queries)/float64(b.N), "queries/op")
+			})
+		}
+	}
+}
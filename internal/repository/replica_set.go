@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// replicaPingTimeout — сколько ждать ответа реплики при проверке здоровья
+const replicaPingTimeout = 2 * time.Second
+
+// primaryKey — ключ контекста с отметкой, что запрос уже обращался к основной базе
+type primaryKey struct{}
+
+// WithReadYourWrites возвращает контекст запроса, в котором после первого обращения
+// к основной базе все чтения тоже идут в неё: так запрос видит собственные записи,
+// даже если реплики отстают. Повторный вызов возвращает ctx без изменений.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(primaryKey{}).(*atomic.Bool); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, primaryKey{}, new(atomic.Bool))
+}
+
+// markPrimary отмечает, что запрос из ctx обратился к основной базе
+func markPrimary(ctx context.Context) {
+	if used, ok := ctx.Value(primaryKey{}).(*atomic.Bool); ok {
+		used.Store(true)
+	}
+}
+
+// usedPrimary сообщает, обращался ли запрос из ctx к основной базе
+func usedPrimary(ctx context.Context) bool {
+	used, ok := ctx.Value(primaryKey{}).(*atomic.Bool)
+	return ok && used.Load()
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// ReplicaSet распределяет чтения по репликам по кругу. Реплика, не ответившая
+// на проверку или вернувшая ошибку, пропускается до следующей успешной проверки;
+// если здоровых реплик нет, чтения идут в основную базу.
+type ReplicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewReplicaSet проверяет реплики и, если interval больше нуля, перепроверяет
+// их в фоне с этим интервалом до вызова Close
+func NewReplicaSet(dbs []*sql.DB, interval time.Duration) *ReplicaSet {
+	s := &ReplicaSet{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, db := range dbs {
+		rep := &replica{db: db}
+		rep.healthy.Store(true) // в лог при первой проверке попадут только недоступные
+		s.replicas = append(s.replicas, rep)
+	}
+	s.check(context.Background())
+
+	if interval > 0 {
+		go s.run(interval)
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+func (s *ReplicaSet) run(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.check(context.Background())
+		}
+	}
+}
+
+// check пингует каждую реплику и обновляет её состояние
+func (s *ReplicaSet) check(ctx context.Context) {
+	for i, rep := range s.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := rep.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				logrus.Infof("Реплика %d снова доступна", i)
+			} else {
+				logrus.Warnf("Реплика %d недоступна, чтения идут в основную базу: %v", i, err)
+			}
+		}
+	}
+}
+
+// pick возвращает следующую здоровую реплику или nil, если чтение из ctx должно
+// идти в основную базу: внутри транзакции, после обращения запроса к основной базе
+// или когда здоровых реплик нет. Работает и с nil ReplicaSet.
+func (s *ReplicaSet) pick(ctx context.Context) *replica {
+	if s == nil || len(s.replicas) == 0 || usedPrimary(ctx) {
+		return nil
+	}
+	if _, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return nil
+	}
+
+	healthy := make([]*replica, 0, len(s.replicas))
+	for _, rep := range s.replicas {
+		if rep.healthy.Load() {
+			healthy = append(healthy, rep)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	return healthy[s.next.Add(1)%uint64(len(healthy))]
+}
+
+// markDown исключает реплику из выбора до следующей успешной проверки
+func (s *ReplicaSet) markDown(rep *replica, err error) {
+	if rep.healthy.Swap(false) {
+		logrus.Warnf("Чтение с реплики не удалось, она исключена до следующей проверки: %v", err)
+	}
+}
+
+// Close останавливает фоновую проверку и закрывает соединения с репликами
+func (s *ReplicaSet) Close() error {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+
+	var firstErr error
+	for _, rep := range s.replicas {
+		if err := rep.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
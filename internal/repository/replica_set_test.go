@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// openReplica открывает базу SQLite, которая изображает реплику. Если каталога
+// нет, база не открывается и реплика не проходит проверку.
+func openReplica(t *testing.T, dir string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", sqliteDSN(filepath.Join(dir, "replica.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestReplicaSet_Pick(t *testing.T) {
+	first, second := openReplica(t, t.TempDir()), openReplica(t, t.TempDir())
+	broken := openReplica(t, filepath.Join(t.TempDir(), "missing"))
+	set := NewReplicaSet([]*sql.DB{first, broken, second}, 0)
+	t.Cleanup(func() { set.Close() })
+	ctx := context.Background()
+
+	t.Run("RoundRobinOverHealthy", func(t *testing.T) {
+		seen := map[*sql.DB]int{}
+		for i := 0; i < 4; i++ {
+			rep := set.pick(ctx)
+			if rep == nil {
+				t.Fatal("expected a replica")
+			}
+			seen[rep.db]++
+		}
+		if seen[first] != 2 || seen[second] != 2 || seen[broken] != 0 {
+			t.Errorf("expected reads to alternate between healthy replicas, got %v", seen)
+		}
+	})
+
+	t.Run("MarkDown", func(t *testing.T) {
+		set.markDown(set.replicas[0], errors.New("connection reset"))
+		for i := 0; i < 3; i++ {
+			if rep := set.pick(ctx); rep == nil || rep.db != second {
+				t.Fatalf("expected only the second replica, got %v", rep)
+			}
+		}
+
+		// Следующая проверка возвращает ответившую реплику
+		set.check(ctx)
+		if !set.replicas[0].healthy.Load() || set.replicas[1].healthy.Load() {
+			t.Error("expected check to restore the first replica only")
+		}
+	})
+
+	t.Run("StickToPrimary", func(t *testing.T) {
+		tracked := WithReadYourWrites(ctx)
+		if set.pick(tracked) == nil {
+			t.Error("expected a replica before the request touched the primary")
+		}
+		markPrimary(tracked)
+		if rep := set.pick(tracked); rep != nil {
+			t.Error("expected reads after a write to go to the primary")
+		}
+		if WithReadYourWrites(tracked) != tracked {
+			t.Error("expected nested WithReadYourWrites to keep the tracker")
+		}
+
+		tx, err := first.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		if rep := set.pick(context.WithValue(ctx, sqlTxKey{}, tx)); rep != nil {
+			t.Error("expected reads inside a transaction to go to the primary")
+		}
+
+		var none *ReplicaSet
+		if none.pick(ctx) != nil {
+			t.Error("expected nil replica set to read from the primary")
+		}
+	})
+}
+
+func TestPostgresOrderRepository_ReadFallsBackToPrimary(t *testing.T) {
+	primary := openReplica(t, t.TempDir())
+	t.Cleanup(func() { primary.Close() })
+	replica := openReplica(t, t.TempDir())
+	set := NewReplicaSet([]*sql.DB{replica}, 0)
+	t.Cleanup(func() { set.Close() })
+	repo := NewPostgresOrderRepository(primary, WithReadReplicas(set)).(*PostgresOrderRepository)
+
+	var used []sqlConn
+	err := repo.read(context.Background(), func(q sqlConn) error {
+		used = append(used, q)
+		if q == sqlConn(replica) {
+			return errors.New("replica is lagging too far behind")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected read to succeed on the primary, got %v", err)
+	}
+	if len(used) != 2 || used[0] != sqlConn(replica) || used[1] != sqlConn(primary) {
+		t.Errorf("expected replica then primary, got %v", used)
+	}
+	if set.pick(context.Background()) != nil {
+		t.Error("expected failed replica to be excluded until the next check")
+	}
+
+	// Отсутствие строки — не сбой реплики
+	set.check(context.Background())
+	err = repo.read(context.Background(), func(q sqlConn) error { return sql.ErrNoRows })
+	if !errors.Is(err, sql.ErrNoRows) || set.pick(context.Background()) == nil {
+		t.Errorf("expected sql.ErrNoRows without excluding the replica, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"database/sql"
+	"order_service/internal/entity"
+	"strings"
+)
+
+// orderQuerySQL строит условие WHERE, ORDER BY и LIMIT выборки заказов
+// для Postgres и SQLite. placeholder возвращает плейсхолдер n-го аргумента.
+//
+// Курсор сравнивается как кортеж (created_at, id), поэтому выдача стабильна,
+// даже если у заказов совпадает время создания.
+func orderQuerySQL(q entity.OrderQuery, placeholder func(n int) string) (string, []interface{}) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return placeholder(len(args))
+	}
+
+	var conditions []string
+	if q.UserID != 0 {
+		conditions = append(conditions, "user_id = "+arg(q.UserID))
+	}
+	if !q.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if len(q.Statuses) > 0 {
+		in := make([]string, len(q.Statuses))
+		for i, status := range q.Statuses {
+			in[i] = arg(string(status))
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(in, ", ")+")")
+	}
+	if !q.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(q.CreatedFrom.UTC()))
+	}
+	if !q.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(q.CreatedTo.UTC()))
+	}
+
+	direction, cmp := "ASC", ">"
+	if q.Sort == entity.SortDesc {
+		direction, cmp = "DESC", "<"
+	}
+	if q.After != nil {
+		conditions = append(conditions,
+			"(created_at, id) "+cmp+" ("+arg(q.After.CreatedAt.UTC())+", "+arg(q.After.ID)+")")
+	}
+
+	var query string
+	if len(conditions) > 0 {
+		query = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at " + direction + ", id " + direction
+	if q.Limit > 0 {
+		query += " LIMIT " + arg(q.Limit)
+	}
+	return query, args
+}
+
+// orderColumns — колонки orders, которые читает orderFields
+const orderColumns = "id, user_id, currency, total_price, status, created_at, version, deleted_at"
+
+// orderFields возвращает поля заказа для Scan в порядке orderColumns.
+// currency читается раньше total_price: Money.Scan сохраняет уже заданную валюту.
+func orderFields(order *entity.Order) []interface{} {
+	return []interface{}{&order.ID, &order.UserID, &order.TotalPrice.Currency, &order.TotalPrice, &order.Status, &order.CreatedAt, &order.Version, &order.DeletedAt}
+}
+
+// orderTables — таблицы заказов и их товаров: основные или архивные
+type orderTables struct {
+	orders   string
+	items    string
+	archived bool
+}
+
+var (
+	liveTables    = orderTables{orders: "orders", items: "order_items"}
+	archiveTables = orderTables{orders: "orders_archive", items: "order_items_archive", archived: true}
+)
+
+// selectOrderSQL возвращает запрос заказа по ID с плейсхолдером arg; удалённые
+// заказы читаются только с includeDeleted. У архивного заказа дополнительно читается archived_at.
+func (t orderTables) selectOrderSQL(arg string, includeDeleted bool) string {
+	columns := orderColumns
+	if t.archived {
+		columns += ", archived_at"
+	}
+	query := "SELECT " + columns + " FROM " + t.orders + " WHERE id = " + arg
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	return query
+}
+
+// scanFields возвращает поля для Scan строки из selectOrderSQL
+func (t orderTables) scanFields(order *entity.Order) []interface{} {
+	fields := orderFields(order)
+	if t.archived {
+		fields = append(fields, &order.ArchivedAt)
+	}
+	return fields
+}
+
+// archiveOrdersSQL возвращает запросы переноса заказов в архив. match — условие
+// на ID заказов, например "= ANY($1)". Товары, история и резервы удаляются из
+// основных таблиц каскадом вместе с заказом.
+func archiveOrdersSQL(match string) []string {
+	return []string{
+		`INSERT INTO orders_archive (id, user_id, total_price, currency, status, created_at, updated_at, version, deleted_at)
+		SELECT id, user_id, total_price, currency, status, created_at, updated_at, version, deleted_at FROM orders WHERE id ` + match,
+		`INSERT INTO order_items_archive (id, order_id, product_id, name, quantity, price)
+		SELECT id, order_id, product_id, name, quantity, price FROM order_items WHERE order_id ` + match,
+		`INSERT INTO order_status_history_archive (id, order_id, old_status, new_status, actor, reason, created_at)
+		SELECT id, order_id, old_status, new_status, actor, reason, created_at FROM order_status_history WHERE order_id ` + match,
+		"DELETE FROM orders WHERE id " + match,
+	}
+}
+
+// statusStrings переводит статусы в строки для параметров запроса
+func statusStrings(statuses []entity.OrderStatus) []string {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+	return values
+}
+
+// statusHistorySQL возвращает запрос истории статусов заказа из основной и архивной таблиц
+func statusHistorySQL(arg string) string {
+	const columns = "id, order_id, COALESCE(old_status, ''), new_status, actor, COALESCE(reason, ''), created_at"
+	return "SELECT " + columns + " FROM order_status_history WHERE order_id = " + arg +
+		" UNION ALL SELECT " + columns + " FROM order_status_history_archive WHERE order_id = " + arg +
+		" ORDER BY created_at, id"
+}
+
+// expectOneRow проверяет, что условное обновление затронуло строку. Иначе
+// строку успели изменить с момента чтения.
+func expectOneRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrConcurrentModification
+	}
+	return nil
+}
+
+// orderItemColumns — колонки order_items, которые читает scanOrderItems
+const orderItemColumns = "id, order_id, product_id, name, quantity, price"
+
+// orderIDs возвращает ID заказов страницы
+func orderIDs(orders []entity.Order) []int64 {
+	ids := make([]int64, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+	return ids
+}
+
+// scanOrderItems раскладывает товары, выбранные одним запросом по нескольким заказам,
+// по их заказам. Цена товара хранится в валюте заказа.
+func scanOrderItems(rows *sql.Rows, orders []entity.Order) error {
+	defer rows.Close()
+
+	byID := make(map[int64]*entity.Order, len(orders))
+	for i := range orders {
+		byID[orders[i].ID] = &orders[i]
+	}
+
+	for rows.Next() {
+		var item entity.OrderItem
+		var orderID int64
+		if err := rows.Scan(&item.ID, &orderID, &item.ProductID, &item.Name, &item.Quantity, &item.Price); err != nil {
+			return err
+		}
+		order, ok := byID[orderID]
+		if !ok {
+			continue
+		}
+		item.Price = entity.NewMoney(item.Price.Amount, order.TotalPrice.Currency)
+		order.Items = append(order.Items, item)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// sqlTxKey — ключ контекста, под которым лежит транзакция единицы работы
+type sqlTxKey struct{}
+
+// sqlConn — общие методы *sql.DB и *sql.Tx
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// sqlTransactor реализует Transactor поверх database/sql.
+// Открытая транзакция передаётся через контекст.
+type sqlTransactor struct {
+	db *sql.DB
+}
+
+func (t sqlTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	markPrimary(ctx)
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, sqlTxKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// inTx выполняет fn в транзакции из ctx, а если её нет — в новой
+func (t sqlTransactor) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return t.WithinTransaction(ctx, func(ctx context.Context) error {
+		return fn(ctx.Value(sqlTxKey{}).(*sql.Tx))
+	})
+}
+
+// conn возвращает транзакцию из ctx или саму базу. Обращение отмечается в ctx,
+// чтобы следующие чтения запроса не уходили на отстающие реплики.
+func (t sqlTransactor) conn(ctx context.Context) sqlConn {
+	if tx, ok := ctx.Value(sqlTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	markPrimary(ctx)
+	return t.db
+}
@@ -0,0 +1,636 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"order_service/internal/entity"
+	"strings"
+	"time"
+)
+
+// sqliteNow — текущее время в формате, в котором SQLite-миграции хранят даты.
+// Даты сравниваются как строки, поэтому формат должен совпадать везде.
+const sqliteNow = "strftime('%Y-%m-%d %H:%M:%f', 'now')"
+
+// SQLiteOrderRepository — реализация OrderRepository для однонодовых установок.
+//
+// База открывается с _txlock=immediate: транзакция сразу берёт блокировку записи,
+// поэтому резервы сериализуются так же, как advisory-блокировкой в Postgres.
+type SQLiteOrderRepository struct {
+	sqlTransactor
+}
+
+func NewSQLiteOrderRepository(db *sql.DB) OrderRepository {
+	return &SQLiteOrderRepository{sqlTransactor{db: db}}
+}
+
+// sqliteCutoff возвращает момент now - d в формате дат SQLite
+func sqliteCutoff(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, d time.Duration) (string, error) {
+	var cutoff string
+	modifier := fmt.Sprintf("-%.3f seconds", d.Seconds())
+	err := q.QueryRowContext(ctx, "SELECT strftime('%Y-%m-%d %H:%M:%f', 'now', ?)", modifier).Scan(&cutoff)
+	return cutoff, err
+}
+
+// sqliteIn возвращает плейсхолдеры и аргументы для условия IN
+func sqliteIn(ids []int64) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
+// Create сохраняет заказ и его товары в одной транзакции: в транзакции из ctx или в собственной
+func (r *SQLiteOrderRepository) Create(ctx context.Context, order *entity.Order) (*entity.Order, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO orders (user_id, total_price, currency, status, created_at) VALUES (?, ?, ?, ?, ?)",
+			order.UserID, order.TotalPrice, order.TotalPrice.Currency, order.Status, order.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if order.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		order.Version = 1 // DEFAULT колонки version
+
+		stmt, err := tx.PrepareContext(ctx, "INSERT INTO order_items (order_id, product_id, name, quantity, price) VALUES (?, ?, ?, ?, ?)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i, item := range order.Items {
+			res, err := stmt.ExecContext(ctx, order.ID, item.ProductID, item.Name, item.Quantity, item.Price)
+			if err != nil {
+				return err
+			}
+			if order.Items[i].ID, err = res.LastInsertId(); err != nil {
+				return err
+			}
+		}
+
+		return sqliteInsertOutboxEvent(ctx, tx, entity.OrderCreatedEvent(order))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetOrderByID возвращает неудалённый заказ. Если в orders его нет, заказ ищется в архиве.
+func (r *SQLiteOrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, orderID, false)
+}
+
+// GetOrderByIDIncludingDeleted возвращает заказ, даже если он мягко удалён
+func (r *SQLiteOrderRepository) GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error) {
+	return r.getOrderByID(ctx, orderID, true)
+}
+
+func (r *SQLiteOrderRepository) getOrderByID(ctx context.Context, orderID int64, includeDeleted bool) (*entity.Order, error) {
+	order, err := r.getOrder(ctx, liveTables, orderID, includeDeleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		order, err = r.getOrder(ctx, archiveTables, orderID, includeDeleted)
+	}
+	if err != nil {
+		return nil, orderNotFound(err)
+	}
+	return order, nil
+}
+
+func (r *SQLiteOrderRepository) getOrder(ctx context.Context, tables orderTables, orderID int64, includeDeleted bool) (*entity.Order, error) {
+	var order entity.Order
+	err := r.conn(ctx).QueryRowContext(ctx, tables.selectOrderSQL("?", includeDeleted), orderID).
+		Scan(tables.scanFields(&order)...)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []entity.Order{order}
+	if err := r.loadItems(ctx, tables.items, orders); err != nil {
+		return nil, err
+	}
+	return &orders[0], nil
+}
+
+// Delete помечает заказ удалённым и записывает событие order.deleted. Товары и история
+// остаются для учёта. Отсутствующий или уже удалённый заказ — ErrOrderNotFound,
+// заказ, ожидающий оплаты, — ErrOrderPending.
+func (r *SQLiteOrderRepository) Delete(ctx context.Context, orderID int64) error {
+	return orderNotFound(r.inTx(ctx, func(tx *sql.Tx) error {
+		userID, status, err := r.lockOrder(ctx, tx, orderID, "deleted_at IS NULL")
+		if err != nil {
+			return err
+		}
+		if status == entity.OrderStatusPending {
+			return ErrOrderPending
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE orders SET deleted_at = "+sqliteNow+", version = version + 1, updated_at = "+sqliteNow+" WHERE id = ?",
+			orderID)
+		if err != nil {
+			return err
+		}
+		return sqliteInsertOutboxEvent(ctx, tx, entity.OrderDeletedEvent(userID, orderID, status, time.Now().UTC()))
+	}))
+}
+
+// Restore снимает с заказа пометку удаления и записывает событие order.restored.
+// Удалённый pending-заказ остался без резервов и не восстанавливается — ErrOrderPending.
+func (r *SQLiteOrderRepository) Restore(ctx context.Context, orderID int64) error {
+	return orderNotFound(r.inTx(ctx, func(tx *sql.Tx) error {
+		userID, status, err := r.lockOrder(ctx, tx, orderID, "deleted_at IS NOT NULL")
+		if err != nil {
+			return err
+		}
+		if status == entity.OrderStatusPending {
+			return ErrOrderPending
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE orders SET deleted_at = NULL, version = version + 1, updated_at = "+sqliteNow+" WHERE id = ?",
+			orderID)
+		if err != nil {
+			return err
+		}
+		return sqliteInsertOutboxEvent(ctx, tx, entity.OrderRestoredEvent(userID, orderID, status, time.Now().UTC()))
+	}))
+}
+
+// lockOrder читает владельца и статус заказа, подходящего под condition. Строку
+// защищает блокировка записи, которую транзакция берёт сразу.
+func (r *SQLiteOrderRepository) lockOrder(ctx context.Context, tx *sql.Tx, orderID int64, condition string) (int64, entity.OrderStatus, error) {
+	var userID int64
+	var raw string
+	err := tx.QueryRowContext(ctx, "SELECT user_id, status FROM orders WHERE id = ? AND "+condition, orderID).Scan(&userID, &raw)
+	if err != nil {
+		return 0, "", err
+	}
+	status, err := entity.ParseOrderStatus(raw)
+	return userID, status, err
+}
+
+// ArchiveOrders переносит завершённые заказы старше age вместе с товарами и историей
+// в архивные таблицы. Транзакция сразу берёт блокировку записи, поэтому вызовы не пересекаются.
+func (r *SQLiteOrderRepository) ArchiveOrders(ctx context.Context, age time.Duration, limit int) ([]int64, error) {
+	var orderIDs []int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		orderIDs = nil
+		cutoff, err := sqliteCutoff(ctx, tx, age)
+		if err != nil {
+			return err
+		}
+
+		args := []interface{}{cutoff}
+		for _, status := range entity.ArchivableStatuses {
+			args = append(args, status)
+		}
+		statuses := strings.TrimSuffix(strings.Repeat("?,", len(entity.ArchivableStatuses)), ",")
+		rows, err := tx.QueryContext(ctx,
+			"SELECT id FROM orders WHERE created_at < ? AND status IN ("+statuses+") ORDER BY id LIMIT ?",
+			append(args, limit)...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var orderID int64
+			if err := rows.Scan(&orderID); err != nil {
+				rows.Close()
+				return err
+			}
+			orderIDs = append(orderIDs, orderID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(orderIDs) == 0 {
+			return nil
+		}
+
+		placeholders, args := sqliteIn(orderIDs)
+		for _, query := range archiveOrdersSQL("IN (" + placeholders + ")") {
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
+
+// ReserveStock резервирует quantity единиц товара за заказом, если остаток stock
+// с учётом существующих резервов это позволяет. Транзакция сразу берёт блокировку записи.
+func (r *SQLiteOrderRepository) ReserveStock(ctx context.Context, orderID, productID int64, quantity int64, stock int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var reserved int64
+		err := tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(quantity), 0) FROM reserved_stock WHERE product_id = ?", productID).
+			Scan(&reserved)
+		if err != nil {
+			return err
+		}
+		if stock-reserved < quantity {
+			return fmt.Errorf("%w: product %d", ErrInsufficientStock, productID)
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO reserved_stock (order_id, product_id, quantity) VALUES (?, ?, ?)", orderID, productID, quantity)
+		return err
+	})
+}
+
+// GetReservedStock возвращает суммарный резерв по каждому из товаров
+func (r *SQLiteOrderRepository) GetReservedStock(ctx context.Context, productIDs []int64) (map[int64]int64, error) {
+	reserved := make(map[int64]int64, len(productIDs))
+	if len(productIDs) == 0 {
+		return reserved, nil
+	}
+
+	placeholders, args := sqliteIn(productIDs)
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		"SELECT product_id, SUM(quantity) FROM reserved_stock WHERE product_id IN ("+placeholders+") GROUP BY product_id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, quantity int64
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		reserved[productID] = quantity
+	}
+	return reserved, rows.Err()
+}
+
+func (r *SQLiteOrderRepository) GetAvailableStock(ctx context.Context, productID int64) (int64, error) {
+	var availableStock int64
+	err := r.conn(ctx).QueryRowContext(ctx,
+		`SELECT stock - COALESCE((SELECT SUM(quantity) FROM reserved_stock WHERE product_id = ?), 0)
+		FROM products WHERE id = ?`,
+		productID, productID,
+	).Scan(&availableStock)
+	return availableStock, err
+}
+
+// GetExpiredOrders возвращает ID pending-заказов с резервами старше ttl. Резервы снимает
+// смена статуса на expired; транзакция из ctx держит блокировку записи до своего конца.
+func (r *SQLiteOrderRepository) GetExpiredOrders(ctx context.Context, ttl time.Duration) ([]int64, error) {
+	var orderIDs []int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		orderIDs = nil
+		cutoff, err := sqliteCutoff(ctx, tx, ttl)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT o.id FROM orders o
+			WHERE o.status = ? AND o.deleted_at IS NULL AND EXISTS (
+				SELECT 1 FROM reserved_stock r WHERE r.order_id = o.id AND r.created_at < ?
+			)
+			ORDER BY o.id`,
+			entity.OrderStatusPending, cutoff)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var orderID int64
+			if err := rows.Scan(&orderID); err != nil {
+				return err
+			}
+			orderIDs = append(orderIDs, orderID)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderIDs, nil
+}
+
+func (r *SQLiteOrderRepository) ReleaseReservations(ctx context.Context, orderID int64) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM reserved_stock WHERE order_id = ?", orderID)
+	return err
+}
+
+// UpdateOrderStatus меняет статус заказа и записывает изменение в историю в одной транзакции
+func (r *SQLiteOrderRepository) UpdateOrderStatus(ctx context.Context, change *entity.StatusChange) error {
+	return r.changeStatus(ctx, change, nil)
+}
+
+func (r *SQLiteOrderRepository) CancelOrder(ctx context.Context, userID int64, orderID int64, expectedVersion int64) error {
+	return r.changeStatus(ctx, &entity.StatusChange{
+		OrderID:         orderID,
+		NewStatus:       entity.OrderStatusCanceled,
+		Actor:           entity.UserActor(userID),
+		ExpectedVersion: expectedVersion,
+	}, &userID)
+}
+
+// changeStatus повторяет PostgresOrderRepository.changeStatus; вместо SELECT ... FOR UPDATE
+// строку защищает блокировка записи, которую транзакция берёт сразу.
+func (r *SQLiteOrderRepository) changeStatus(ctx context.Context, change *entity.StatusChange, userID *int64) error {
+	return orderNotFound(r.inTx(ctx, func(tx *sql.Tx) error {
+		query := "SELECT status, user_id, version FROM orders WHERE id = ? AND deleted_at IS NULL"
+		args := []interface{}{change.OrderID}
+		if userID != nil {
+			query = "SELECT status, user_id, version FROM orders WHERE id = ? AND user_id = ? AND deleted_at IS NULL"
+			args = append(args, *userID)
+		}
+
+		var raw string
+		var ownerID, version int64
+		if err := tx.QueryRowContext(ctx, query, args...).Scan(&raw, &ownerID, &version); err != nil {
+			return err
+		}
+		if change.ExpectedVersion != 0 && change.ExpectedVersion != version {
+			return ErrConcurrentModification
+		}
+		current, err := entity.ParseOrderStatus(raw)
+		if err != nil {
+			return err
+		}
+		if err := current.TransitionTo(change.NewStatus); err != nil {
+			return err
+		}
+		change.OldStatus = current
+
+		res, err := tx.ExecContext(ctx,
+			"UPDATE orders SET status = ?, version = version + 1, updated_at = "+sqliteNow+" WHERE id = ? AND version = ?",
+			change.NewStatus, change.OrderID, version)
+		if err := expectOneRow(res, err); err != nil {
+			return err
+		}
+
+		if current == entity.OrderStatusPending {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM reserved_stock WHERE order_id = ?", change.OrderID); err != nil {
+				return err
+			}
+		}
+
+		res, err = tx.ExecContext(ctx,
+			`INSERT INTO order_status_history (order_id, old_status, new_status, actor, reason)
+			VALUES (?, ?, ?, ?, NULLIF(?, ''))`,
+			change.OrderID, change.OldStatus, change.NewStatus, change.Actor, change.Reason,
+		)
+		if err != nil {
+			return err
+		}
+		if change.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		if err := tx.QueryRowContext(ctx, "SELECT created_at FROM order_status_history WHERE id = ?", change.ID).Scan(&change.CreatedAt); err != nil {
+			return err
+		}
+
+		if err := sqliteInsertOutboxEvent(ctx, tx, entity.StatusChangedEvent(ownerID, change)); err != nil {
+			return err
+		}
+
+		return nil
+	}))
+}
+
+// GetStatusHistory возвращает историю статусов заказа, в том числе архивного, в хронологическом порядке
+func (r *SQLiteOrderRepository) GetStatusHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, statusHistorySQL("?"), orderID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []entity.StatusChange{}
+	for rows.Next() {
+		var c entity.StatusChange
+		if err := rows.Scan(&c.ID, &c.OrderID, &c.OldStatus, &c.NewStatus, &c.Actor, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+
+	return history, rows.Err()
+}
+
+func (r *SQLiteOrderRepository) GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) ([]entity.Order, error) {
+	where, args := orderQuerySQL(query, func(int) string { return "?" })
+	rows, err := r.conn(ctx).QueryContext(ctx, "SELECT "+orderColumns+" FROM orders"+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []entity.Order
+	for rows.Next() {
+		var order entity.Order
+		if err := rows.Scan(orderFields(&order)...); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Товары читаются после закрытия курсора: в SQLite одно соединение не держит два запроса сразу
+	if err := r.loadItems(ctx, liveTables.items, orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// loadItems загружает товары всех заказов одним запросом из таблицы table
+func (r *SQLiteOrderRepository) loadItems(ctx context.Context, table string, orders []entity.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	placeholders, args := sqliteIn(orderIDs(orders))
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		"SELECT "+orderItemColumns+" FROM "+table+" WHERE order_id IN ("+placeholders+") ORDER BY order_id, id", args...)
+	if err != nil {
+		return err
+	}
+	return scanOrderItems(rows, orders)
+}
+
+// SAGA methods
+
+func (r *SQLiteOrderRepository) CreateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`INSERT INTO order_sagas (user_id, order_id, step, status, payment_url, error) VALUES (?, ?, ?, ?, ?, ?)`,
+		saga.UserID, nullableID(saga.OrderID), saga.Step, saga.Status, saga.PaymentURL, saga.Error,
+	)
+	if err != nil {
+		return err
+	}
+	if saga.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	return r.conn(ctx).QueryRowContext(ctx, "SELECT created_at, updated_at FROM order_sagas WHERE id = ?", saga.ID).
+		Scan(&saga.CreatedAt, &saga.UpdatedAt)
+}
+
+func (r *SQLiteOrderRepository) UpdateSaga(ctx context.Context, saga *entity.OrderSaga) error {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`UPDATE order_sagas
+		SET order_id = ?, step = ?, status = ?, payment_url = ?, error = ?, updated_at = `+sqliteNow+`
+		WHERE id = ?`,
+		nullableID(saga.OrderID), saga.Step, saga.Status, saga.PaymentURL, saga.Error, saga.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return r.conn(ctx).QueryRowContext(ctx, "SELECT updated_at FROM order_sagas WHERE id = ?", saga.ID).Scan(&saga.UpdatedAt)
+}
+
+func (r *SQLiteOrderRepository) GetStaleSagas(ctx context.Context, olderThan time.Duration) ([]entity.OrderSaga, error) {
+	cutoff, err := sqliteCutoff(ctx, r.conn(ctx), olderThan)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT id, user_id, order_id, step, status, payment_url, error, created_at, updated_at
+		FROM order_sagas
+		WHERE status IN (?, ?) AND updated_at < ?
+		ORDER BY id`,
+		entity.SagaStatusRunning, entity.SagaStatusCompensating, cutoff,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sagas []entity.OrderSaga
+	for rows.Next() {
+		var saga entity.OrderSaga
+		var orderID sql.NullInt64
+		if err := rows.Scan(&saga.ID, &saga.UserID, &orderID, &saga.Step, &saga.Status,
+			&saga.PaymentURL, &saga.Error, &saga.CreatedAt, &saga.UpdatedAt); err != nil {
+			return nil, err
+		}
+		saga.OrderID = orderID.Int64
+		sagas = append(sagas, saga)
+	}
+	return sagas, rows.Err()
+}
+
+// OUTBOX methods
+
+func sqliteInsertOutboxEvent(ctx context.Context, tx *sql.Tx, event entity.OrderEvent) error {
+	record, err := entity.NewOutboxEvent(event)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO outbox (order_id, event_type, payload) VALUES (?, ?, ?)",
+		record.OrderID, record.Type, string(record.Payload),
+	)
+	return err
+}
+
+func (r *SQLiteOrderRepository) GetUnsentEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		`SELECT id, order_id, event_type, payload, created_at
+		FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []entity.OutboxEvent
+	for rows.Next() {
+		var event entity.OutboxEvent
+		var payload string
+		if err := rows.Scan(&event.ID, &event.OrderID, &event.Type, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *SQLiteOrderRepository) MarkEventsSent(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders, args := sqliteIn(ids)
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE outbox SET sent_at = "+sqliteNow+" WHERE id IN ("+placeholders+")", args...)
+	return err
+}
+
+// IDEMPOTENCY methods
+
+func (r *SQLiteOrderRepository) ClaimIdempotencyKey(ctx context.Context, record *entity.IdempotencyRecord, ttl time.Duration) (*entity.IdempotencyRecord, error) {
+	if _, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < "+sqliteNow); err != nil {
+		return nil, err
+	}
+
+	res, err := r.conn(ctx).ExecContext(ctx,
+		`INSERT OR IGNORE INTO idempotency_keys (user_id, key, request_hash, expires_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now', ?))`,
+		record.UserID, record.Key, record.RequestHash, fmt.Sprintf("+%.3f seconds", ttl.Seconds()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 1 {
+		return nil, nil
+	}
+
+	existing := entity.IdempotencyRecord{UserID: record.UserID, Key: record.Key}
+	var response sql.NullString
+	err = r.conn(ctx).QueryRowContext(ctx,
+		`SELECT request_hash, response, created_at, expires_at
+		FROM idempotency_keys WHERE user_id = ? AND key = ?`,
+		record.UserID, record.Key,
+	).Scan(&existing.RequestHash, &response, &existing.CreatedAt, &existing.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.ClaimIdempotencyKey(ctx, record, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		existing.Response = []byte(response.String)
+	}
+	return &existing, nil
+}
+
+func (r *SQLiteOrderRepository) SaveIdempotentResponse(ctx context.Context, userID int64, key string, response []byte) error {
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"UPDATE idempotency_keys SET response = ? WHERE user_id = ? AND key = ?",
+		string(response), userID, key,
+	)
+	return err
+}
+
+func (r *SQLiteOrderRepository) ReleaseIdempotencyKey(ctx context.Context, userID int64, key string) error {
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = ? AND key = ? AND response IS NULL",
+		userID, key,
+	)
+	return err
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"order_service/internal/authz"
+	"order_service/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ArchiveConfig — настройки фонового переноса старых заказов в архив
+type ArchiveConfig struct {
+	Interval  time.Duration // как часто искать заказы для архива
+	Age       time.Duration // возраст заказа, после которого он уходит в архив
+	BatchSize int           // сколько заказов переносится одной транзакцией
+}
+
+// OrderArchiveWorker переносит завершённые заказы старше Age в архивные таблицы,
+// чтобы основные таблицы не росли бесконечно. Архивные заказы доступны по ID.
+type OrderArchiveWorker struct {
+	repo repository.OrderRepository
+	cfg  ArchiveConfig
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewOrderArchiveWorker(repo repository.OrderRepository, cfg ArchiveConfig) *OrderArchiveWorker {
+	return &OrderArchiveWorker{
+		repo: repo,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start запускает цикл архивации и блокируется до вызова Stop
+func (w *OrderArchiveWorker) Start() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.RunOnce(authz.WithSystem(context.Background()))
+		}
+	}
+}
+
+// Stop останавливает цикл и дожидается завершения текущего прохода
+func (w *OrderArchiveWorker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// RunOnce переносит в архив пачки заказов, пока они не закончатся, и возвращает их число
+func (w *OrderArchiveWorker) RunOnce(ctx context.Context) int {
+	var archived int
+	for {
+		orderIDs, err := w.repo.ArchiveOrders(ctx, w.cfg.Age, w.cfg.BatchSize)
+		if err != nil {
+			logrus.Error("Ошибка при переносе заказов в архив: ", err)
+			break
+		}
+		archived += len(orderIDs)
+		if len(orderIDs) < w.cfg.BatchSize {
+			break
+		}
+	}
+
+	if archived > 0 {
+		logrus.Infof("Перенесено в архив заказов: %d", archived)
+	}
+	return archived
+}
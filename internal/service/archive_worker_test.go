@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	RepoMocks "order_service/internal/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderArchiveWorker_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	age := 365 * 24 * time.Hour
+	worker := NewOrderArchiveWorker(mockRepo, ArchiveConfig{Interval: time.Hour, Age: age, BatchSize: 2})
+
+	t.Run("ArchivesUntilBatchIsNotFull", func(t *testing.T) {
+		// Подготовка
+		gomock.InOrder(
+			mockRepo.EXPECT().ArchiveOrders(gomock.Any(), age, 2).Return([]int64{1, 2}, nil),
+			mockRepo.EXPECT().ArchiveOrders(gomock.Any(), age, 2).Return([]int64{3}, nil),
+		)
+
+		// Выполнение
+		archived := worker.RunOnce(t.Context())
+
+		// Проверка
+		if archived != 3 {
+			t.Errorf("expected 3 archived orders, got %d", archived)
+		}
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		// Подготовка
+		gomock.InOrder(
+			mockRepo.EXPECT().ArchiveOrders(gomock.Any(), age, 2).Return([]int64{1, 2}, nil),
+			mockRepo.EXPECT().ArchiveOrders(gomock.Any(), age, 2).Return(nil, errors.New("database error")),
+		)
+
+		// Выполнение
+		archived := worker.RunOnce(t.Context())
+
+		// Проверка
+		if archived != 2 {
+			t.Errorf("expected 2 archived orders before the error, got %d", archived)
+		}
+	})
+}
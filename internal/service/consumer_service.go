@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"strings"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -13,12 +14,14 @@ const (
 )
 
 type Hundler interface {
-	HandleMessage(message []byte, topic kafka.TopicPartition, cn int64) error
+	HandleMessage(ctx context.Context, message []byte, topic kafka.TopicPartition, cn int64) error
 }
 
 type Consumer struct {
 	consumer       *kafka.Consumer
 	handler        Hundler
+	ctx            context.Context // отменяется в Stop и прерывает обработку сообщения
+	cancel         context.CancelFunc
 	stop           bool
 	consumerNumber int64
 }
@@ -41,9 +44,12 @@ func NewConsumer(handler Hundler, address []string, topic, consumerGroup string,
 	if err := c.Subscribe(topic, nil); err != nil {
 		return nil, err
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Consumer{
 		consumer:       c,
 		handler:        handler,
+		ctx:            ctx,
+		cancel:         cancel,
 		consumerNumber: consumerNumber,
 	}, nil
 }
@@ -60,7 +66,7 @@ func (c *Consumer) Start() {
 			continue
 		}
 
-		if err := c.handler.HandleMessage(kafkaMsg.Value, kafkaMsg.TopicPartition, c.consumerNumber); err != nil {
+		if err := c.handler.HandleMessage(c.ctx, kafkaMsg.Value, kafkaMsg.TopicPartition, c.consumerNumber); err != nil {
 			logrus.Error(err)
 			continue
 		}
@@ -75,6 +81,7 @@ func (c *Consumer) Start() {
 
 func (c *Consumer) Stop() error {
 	c.stop = true
+	c.cancel()
 	if _, err := c.consumer.Commit(); err != nil {
 		return err
 	}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"order_service/internal/authz"
+	"order_service/internal/domainerr"
+	"order_service/internal/entity"
+	"order_service/internal/productpb"
+	"order_service/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sagaActor — инициатор смены статуса при компенсации саги
+var sagaActor = entity.SystemActor("create-order-saga")
+
+// createOrderSaga хранит данные, которые шаги саги создания заказа передают друг другу.
+//
+// Шаг persist_order сохраняет заказ, резервирует товары и записывает шаг саги
+// с номером заказа в одной транзакции БД, поэтому при ошибке внутри шага
+// компенсировать нечего, а после фиксации восстановление саги всегда знает
+// заказ, с которого нужно снять резервы и перевести его в failed.
+type createOrderSaga struct {
+	service    *OrderService
+	userID     int64
+	items      []entity.OrderItem
+	productIDs []int64
+	totalPrice entity.Money
+
+	stockMap  map[int64]*productpb.ProductStockInfo
+	order     *entity.Order
+	committed bool
+}
+
+func (c *createOrderSaga) steps(state *entity.OrderSaga) []sagaStep {
+	return []sagaStep{
+		{
+			name:   entity.SagaStepValidateStock,
+			action: c.validateStock,
+		},
+		{
+			name: entity.SagaStepPersistOrder,
+			action: func(ctx context.Context) error {
+				return c.persistOrder(ctx, state)
+			},
+			compensate: func(ctx context.Context) error {
+				return c.discardOrder(ctx, state.Error)
+			},
+			savesState: true,
+		},
+		{
+			name: entity.SagaStepRequestPaymentLink,
+			action: func(ctx context.Context) error {
+				payment, err := c.service.paymentClient.GeneratePaymentLink(ctx, c.userID, c.order.ID, c.order.TotalPrice)
+				if err != nil {
+					return fmt.Errorf("failed to get payment link: %w", err)
+				}
+				state.PaymentURL = payment.PaymentUrl
+				return nil
+			},
+		},
+	}
+}
+
+// validateStock проверяет наличие товаров и рассчитывает цены на стороне сервера
+func (c *createOrderSaga) validateStock(ctx context.Context) error {
+	s := c.service
+
+	// Проверка наличия продуктов и их стока
+	stockMap, err := s.productClient.GetProductStock(ctx, c.productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get product stock: %w", err)
+	}
+
+	// Уже зарезервированное неоплаченными заказами недоступно для покупки
+	reservedStock, err := s.repo.GetReservedStock(ctx, c.productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+
+	// Неизвестные товары — ошибка запроса: сообщаем обо всех сразу
+	var unknown domainerr.ValidationError
+	for index, item := range c.items {
+		if _, exists := stockMap[item.ProductID]; !exists {
+			unknown.Add(fmt.Sprintf("items[%d].product_id", index), "refers to unknown product %d", item.ProductID)
+		}
+	}
+	if err := unknown.Err(); err != nil {
+		return err
+	}
+
+	var computedTotal entity.Money
+	for index, item := range c.items {
+		availableStock := stockMap[item.ProductID]
+		if (availableStock.Stock - reservedStock[item.ProductID]) < item.Quantity {
+			return domainerr.New(domainerr.InsufficientStock, "not enough stock for product %d", item.ProductID)
+		}
+		price := productPrice(availableStock)
+		if price.Amount <= 0 {
+			return fmt.Errorf("product %d has no price", item.ProductID)
+		}
+
+		// Цена фиксируется на момент заказа и берётся из Product Service, а не от клиента
+		c.items[index].Name = availableStock.Name
+		c.items[index].Price = price
+		if index == 0 {
+			computedTotal = entity.NewMoney(0, price.Currency)
+		}
+		computedTotal, err = computedTotal.Add(c.items[index].LineTotal())
+		if err != nil {
+			return fmt.Errorf("product %d: %w", item.ProductID, err)
+		}
+	}
+
+	// Клиентская сумма только сверяется с серверной
+	if computedTotal != c.totalPrice {
+		return domainerr.New(domainerr.InvalidArgument, "total price mismatch: expected %s %s, got %s %s",
+			computedTotal, computedTotal.Currency, c.totalPrice, c.totalPrice.Currency)
+	}
+
+	c.stockMap = stockMap
+	return nil
+}
+
+// persistOrder сохраняет заказ, резервирует его товары и отмечает шаг саги в одной транзакции
+func (c *createOrderSaga) persistOrder(ctx context.Context, state *entity.OrderSaga) error {
+	repo := c.service.repo
+	order := &entity.Order{
+		UserID:     c.userID,
+		Items:      c.items,
+		TotalPrice: c.totalPrice,
+		Status:     entity.OrderStatusPending,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	// Состояние саги меняется только после фиксации, чтобы откат не оставил в нём чужой заказ
+	saved := *state
+	err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, order); err != nil {
+			return err
+		}
+		for _, item := range order.Items {
+			err := repo.ReserveStock(ctx, order.ID, item.ProductID, item.Quantity, c.stockMap[item.ProductID].Stock)
+			if errors.Is(err, repository.ErrInsufficientStock) {
+				return domainerr.Wrap(domainerr.InsufficientStock, err, "not enough stock for product %d", item.ProductID)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to reserve stock: %w", err)
+			}
+		}
+
+		saved.OrderID = order.ID
+		saved.Step = entity.SagaStepPersistOrder
+		if err := repo.UpdateSaga(ctx, &saved); err != nil {
+			return fmt.Errorf("failed to save saga step %s: %w", saved.Step, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*state = saved
+	c.order = order
+	c.committed = true
+	return nil
+}
+
+// discardOrder снимает резервы сохранённого заказа и переводит его в failed
+func (c *createOrderSaga) discardOrder(ctx context.Context, reason string) error {
+	if !c.committed {
+		return nil
+	}
+	if err := c.service.repo.ReleaseReservations(ctx, c.order.ID); err != nil {
+		return err
+	}
+	return c.service.failOrder(ctx, c.order.ID, reason)
+}
+
+// failOrder переводит неоплаченный заказ в failed. Заказ, который уже ушёл
+// из pending (например, оплачен) или не был сохранён, компенсировать не нужно.
+func (s *OrderService) failOrder(ctx context.Context, orderID int64, reason string) error {
+	err := s.repo.UpdateOrderStatus(ctx, &entity.StatusChange{
+		OrderID:   orderID,
+		NewStatus: entity.OrderStatusFailed,
+		Actor:     sagaActor,
+		Reason:    reason,
+	})
+	var transitionErr *entity.TransitionError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &transitionErr) && transitionErr.From != entity.OrderStatusPending:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	}
+	return err
+}
+
+// RecoverSagas завершает или откатывает саги, которые не обновлялись дольше staleAfter,
+// например после падения процесса. Возвращает количество обработанных саг.
+func (s *OrderService) RecoverSagas(ctx context.Context, staleAfter time.Duration) (int, error) {
+	if err := authz.Authorize(ctx, authz.ActionRecoverSagas, 0); err != nil {
+		return 0, err
+	}
+
+	sagas, err := s.repo.GetStaleSagas(ctx, staleAfter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load unfinished sagas: %w", err)
+	}
+
+	recovered := 0
+	for i := range sagas {
+		state := &sagas[i]
+		if err := s.recoverSaga(ctx, state); err != nil {
+			logrus.Errorf("Сага %d: восстановление не удалось: %v", state.ID, err)
+			continue
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+func (s *OrderService) recoverSaga(ctx context.Context, state *entity.OrderSaga) error {
+	// Ссылка на оплату уже получена — осталось только отметить завершение
+	if state.Status == entity.SagaStatusRunning && state.Step == entity.SagaStepRequestPaymentLink {
+		state.Status = entity.SagaStatusCompleted
+		return s.repo.UpdateSaga(ctx, state)
+	}
+
+	// Пользователь уже получил ошибку, поэтому незавершённая сага откатывается
+	state.Status = entity.SagaStatusCompensating
+	if state.Error == "" {
+		state.Error = "interrupted before completion"
+	}
+	if err := s.repo.UpdateSaga(ctx, state); err != nil {
+		return err
+	}
+
+	if state.OrderID != 0 {
+		if err := s.repo.ReleaseReservations(ctx, state.OrderID); err != nil {
+			return err
+		}
+		if err := s.failOrder(ctx, state.OrderID, state.Error); err != nil {
+			return err
+		}
+	}
+
+	state.Status = entity.SagaStatusCompensated
+	return s.repo.UpdateSaga(ctx, state)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"order_service/internal/authz"
+	"order_service/internal/delivery/grpcclient"
+	"order_service/internal/entity"
+	"order_service/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExpiryConfig — настройки фонового снятия просроченных резервов
+type ExpiryConfig struct {
+	Interval               time.Duration // как часто проверять резервы
+	TTL                    time.Duration // сколько живёт резерв неоплаченного заказа
+	InvalidatePaymentLinks bool          // аннулировать ли ссылку на оплату истёкшего заказа
+}
+
+// ReservationExpiryWorker переводит в статус expired заказы с резервами старше TTL,
+// чтобы брошенные корзины не держали товар. Резерв снимается той же транзакцией,
+// что меняет статус, поэтому товар освобождается, только если заказ действительно истёк.
+type ReservationExpiryWorker struct {
+	repo          repository.OrderRepository
+	paymentClient grpcclient.PaymentServiceClientInterface
+	cfg           ExpiryConfig
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+func NewReservationExpiryWorker(repo repository.OrderRepository, paymentClient grpcclient.PaymentServiceClientInterface, cfg ExpiryConfig) *ReservationExpiryWorker {
+	return &ReservationExpiryWorker{
+		repo:          repo,
+		paymentClient: paymentClient,
+		cfg:           cfg,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start запускает цикл проверки и блокируется до вызова Stop
+func (w *ReservationExpiryWorker) Start() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.RunOnce(authz.WithSystem(context.Background()))
+		}
+	}
+}
+
+// Stop останавливает цикл и дожидается завершения текущей проверки
+func (w *ReservationExpiryWorker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// RunOnce выполняет одну проверку и возвращает ID заказов, переведённых в expired
+func (w *ReservationExpiryWorker) RunOnce(ctx context.Context) []int64 {
+	var expired []int64
+	err := w.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		expired = nil
+		// Заказы блокируются до конца транзакции: оплата не может проскочить между выборкой и сменой статуса
+		orderIDs, err := w.repo.GetExpiredOrders(ctx, w.cfg.TTL)
+		if err != nil {
+			return err
+		}
+		for _, orderID := range orderIDs {
+			change := &entity.StatusChange{
+				OrderID:   orderID,
+				NewStatus: entity.OrderStatusExpired,
+				Actor:     entity.SystemActor("reservation-expiry"),
+				Reason:    "reservation expired",
+			}
+			if err := w.repo.UpdateOrderStatus(ctx, change); err != nil {
+				return fmt.Errorf("failed to expire order %d: %w", orderID, err)
+			}
+			expired = append(expired, orderID)
+		}
+		return nil
+	})
+	if err != nil {
+		// Резервы остались на месте, поэтому следующая проверка найдёт эти заказы снова
+		logrus.Error("Ошибка при переводе просроченных заказов в expired: ", err)
+		return nil
+	}
+
+	for _, orderID := range expired {
+		if w.cfg.InvalidatePaymentLinks {
+			if err := w.paymentClient.InvalidatePaymentLink(ctx, orderID); err != nil {
+				logrus.Errorf("Не удалось аннулировать ссылку на оплату заказа %d: %v", orderID, err)
+			}
+		}
+	}
+
+	if len(expired) > 0 {
+		logrus.Infof("Просрочено заказов: %d", len(expired))
+	}
+	return expired
+}
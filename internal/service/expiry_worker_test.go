@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	GrpcMocks "order_service/internal/delivery/grpcclient/mocks"
+	"order_service/internal/entity"
+	RepoMocks "order_service/internal/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+// expiredStatus проверяет, что заказ переводится в expired от имени воркера
+func expiredStatus(orderID int64) gomock.Matcher {
+	return gomock.Cond(func(change *entity.StatusChange) bool {
+		return change.OrderID == orderID &&
+			change.NewStatus == entity.OrderStatusExpired &&
+			change.Actor == entity.SystemActor("reservation-expiry") &&
+			change.Reason == "reservation expired"
+	})
+}
+
+func TestReservationExpiryWorker_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockPaymentClient := GrpcMocks.NewMockPaymentServiceClientInterface(ctrl)
+	mockRepo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}).AnyTimes()
+
+	t.Run("ExpiresOrders", func(t *testing.T) {
+		// Подготовка
+		worker := NewReservationExpiryWorker(mockRepo, mockPaymentClient, ExpiryConfig{Interval: time.Minute, TTL: 10 * time.Minute})
+		mockRepo.EXPECT().GetExpiredOrders(gomock.Any(), 10*time.Minute).Return([]int64{1, 2}, nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), expiredStatus(1)).Return(nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), expiredStatus(2)).Return(nil)
+
+		// Выполнение
+		expired := worker.RunOnce(t.Context())
+
+		// Проверка
+		if len(expired) != 2 {
+			t.Errorf("expected 2 expired orders, got %v", expired)
+		}
+	})
+
+	t.Run("StatusErrorRollsBackSweep", func(t *testing.T) {
+		// Подготовка
+		worker := NewReservationExpiryWorker(mockRepo, mockPaymentClient, ExpiryConfig{Interval: time.Minute, TTL: time.Minute, InvalidatePaymentLinks: true})
+		mockRepo.EXPECT().GetExpiredOrders(gomock.Any(), time.Minute).Return([]int64{1, 2}, nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), expiredStatus(1)).Return(nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), expiredStatus(2)).Return(errors.New("connection reset"))
+
+		// Выполнение
+		expired := worker.RunOnce(t.Context())
+
+		// Проверка: транзакция откатывается, ссылки на оплату не трогаются
+		if expired != nil {
+			t.Errorf("expected no expired orders, got %v", expired)
+		}
+	})
+
+	t.Run("InvalidationErrorDoesNotStopSweep", func(t *testing.T) {
+		// Подготовка
+		worker := NewReservationExpiryWorker(mockRepo, mockPaymentClient, ExpiryConfig{Interval: time.Minute, TTL: time.Minute, InvalidatePaymentLinks: true})
+		mockRepo.EXPECT().GetExpiredOrders(gomock.Any(), time.Minute).Return([]int64{1, 2}, nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockPaymentClient.EXPECT().InvalidatePaymentLink(gomock.Any(), int64(1)).Return(errors.New("payment service unavailable"))
+		mockPaymentClient.EXPECT().InvalidatePaymentLink(gomock.Any(), int64(2)).Return(nil)
+
+		// Выполнение
+		expired := worker.RunOnce(t.Context())
+
+		// Проверка
+		if len(expired) != 2 {
+			t.Errorf("expected 2 expired orders, got %v", expired)
+		}
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		// Подготовка
+		worker := NewReservationExpiryWorker(mockRepo, mockPaymentClient, ExpiryConfig{Interval: time.Minute, TTL: time.Minute})
+		mockRepo.EXPECT().GetExpiredOrders(gomock.Any(), time.Minute).Return(nil, errors.New("database error"))
+
+		// Выполнение
+		expired := worker.RunOnce(t.Context())
+
+		// Проверка
+		if expired != nil {
+			t.Errorf("expected no expired orders, got %v", expired)
+		}
+	})
+}
+
+func TestReservationExpiryWorker_StartStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockPaymentClient := GrpcMocks.NewMockPaymentServiceClientInterface(ctrl)
+
+	swept := make(chan struct{}, 1)
+	mockRepo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}).MinTimes(1)
+	mockRepo.EXPECT().GetExpiredOrders(gomock.Any(), time.Minute).DoAndReturn(func(_ any, _ time.Duration) ([]int64, error) {
+		select {
+		case swept <- struct{}{}:
+		default:
+		}
+		return nil, nil
+	}).MinTimes(1)
+
+	worker := NewReservationExpiryWorker(mockRepo, mockPaymentClient, ExpiryConfig{Interval: 10 * time.Millisecond, TTL: time.Minute})
+	go worker.Start()
+
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not run within 1s")
+	}
+	worker.Stop()
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"order_service/internal/authz"
+	"order_service/internal/domainerr"
+	"order_service/internal/entity"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrIdempotencyKeyReused — ключ уже использован с другим телом запроса
+	ErrIdempotencyKeyReused = domainerr.New(domainerr.Conflict, "idempotency key reused with a different request")
+	// ErrIdempotencyKeyInProgress — первый запрос с этим ключом ещё выполняется
+	ErrIdempotencyKeyInProgress = domainerr.New(domainerr.Conflict, "request with this idempotency key is still in progress")
+)
+
+// CreateOrderIdempotent создаёт заказ не более одного раза на ключ пользователя.
+// Повтор с тем же телом возвращает сохранённый ответ первого запроса.
+func (s *OrderService) CreateOrderIdempotent(ctx context.Context, userID int64, key string, items []entity.OrderItem, totalPrice entity.Money) (*entity.PaymentResponse, error) {
+	// Проверяем права до того, как ключ будет занят
+	if err := authz.Authorize(ctx, authz.ActionCreate, userID); err != nil {
+		return nil, err
+	}
+
+	hash, err := requestHash(items, totalPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ClaimIdempotencyKey(ctx, &entity.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: hash,
+	}, s.idempotencyTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if existing != nil {
+		if existing.RequestHash != hash {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if len(existing.Response) == 0 {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		var payment entity.PaymentResponse
+		if err := json.Unmarshal(existing.Response, &payment); err != nil {
+			return nil, fmt.Errorf("failed to decode stored response: %w", err)
+		}
+		return &payment, nil
+	}
+
+	payment, err := s.CreateOrder(ctx, userID, items, totalPrice)
+
+	// Ключ освобождается или получает ответ, даже если клиент уже отключился
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		// Неудачный запрос не запоминается: клиент может повторить его с тем же ключом
+		if releaseErr := s.repo.ReleaseIdempotencyKey(ctx, userID, key); releaseErr != nil {
+			logrus.Errorf("Не удалось освободить ключ идемпотентности %q: %v", key, releaseErr)
+		}
+		return nil, err
+	}
+
+	response, err := json.Marshal(payment)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveIdempotentResponse(ctx, userID, key, response); err != nil {
+		// Заказ уже создан, поэтому ответ возвращается; повтор получит 409 до истечения ключа
+		logrus.Errorf("Не удалось сохранить ответ для ключа идемпотентности %q: %v", key, err)
+	}
+	return payment, nil
+}
+
+// requestHash возвращает SHA-256 тела запроса создания заказа
+func requestHash(items []entity.OrderItem, totalPrice entity.Money) (string, error) {
+	body, err := json.Marshal(struct {
+		Items      []entity.OrderItem `json:"items"`
+		TotalPrice entity.Money       `json:"total_price"`
+	}{items, totalPrice})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
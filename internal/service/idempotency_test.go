@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"order_service/internal/authz"
+	GrpcMocks "order_service/internal/delivery/grpcclient/mocks"
+	"order_service/internal/entity"
+	RepoMocks "order_service/internal/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestOrderService_CreateOrderIdempotent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockProductClient := GrpcMocks.NewMockProductServiceClientInterface(ctrl)
+	mockPaymentClient := GrpcMocks.NewMockPaymentServiceClientInterface(ctrl)
+	service := NewOrderService(mockRepo, mockProductClient, mockPaymentClient)
+
+	userID := int64(1)
+	key := "3f1c2a"
+	items := []entity.OrderItem{{ProductID: 1, Quantity: 2, Price: kzt(5000)}}
+	totalPrice := kzt(10000)
+	hash, err := requestHash(items, totalPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any(), DefaultIdempotencyTTL).Return(&entity.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: hash,
+			Response:    []byte(`{"payment_url":"http://payment.com/link"}`),
+		}, nil)
+
+		// Выполнение
+		result, err := service.CreateOrderIdempotent(authz.WithSystem(context.Background()), userID, key, items, totalPrice)
+
+		// Проверка
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.PaymentURL != "http://payment.com/link" {
+			t.Errorf("expected stored payment URL, got %v", result.PaymentURL)
+		}
+	})
+
+	t.Run("KeyReused", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any(), DefaultIdempotencyTTL).Return(&entity.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: "other",
+			Response:    []byte(`{"payment_url":"http://payment.com/link"}`),
+		}, nil)
+
+		// Выполнение
+		_, err := service.CreateOrderIdempotent(authz.WithSystem(context.Background()), userID, key, items, totalPrice)
+
+		// Проверка
+		if !errors.Is(err, ErrIdempotencyKeyReused) {
+			t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+		}
+	})
+
+	t.Run("InProgress", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any(), DefaultIdempotencyTTL).Return(&entity.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: hash,
+		}, nil)
+
+		// Выполнение
+		_, err := service.CreateOrderIdempotent(authz.WithSystem(context.Background()), userID, key, items, totalPrice)
+
+		// Проверка
+		if !errors.Is(err, ErrIdempotencyKeyInProgress) {
+			t.Errorf("expected ErrIdempotencyKeyInProgress, got %v", err)
+		}
+	})
+
+	t.Run("FailedRequestReleasesKey", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any(), DefaultIdempotencyTTL).
+			DoAndReturn(func(_ context.Context, record *entity.IdempotencyRecord, _ time.Duration) (*entity.IdempotencyRecord, error) {
+				if record.UserID != userID || record.Key != key || record.RequestHash != hash {
+					t.Errorf("unexpected record %+v", record)
+				}
+				return nil, nil
+			})
+		mockRepo.EXPECT().CreateSaga(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+		mockRepo.EXPECT().ReleaseIdempotencyKey(gomock.Any(), userID, key).Return(nil)
+
+		// Выполнение
+		_, err := service.CreateOrderIdempotent(authz.WithSystem(context.Background()), userID, key, items, totalPrice)
+
+		// Проверка
+		if err == nil || err.Error() != "failed to start order saga: database error" {
+			t.Errorf("expected saga start error, got %v", err)
+		}
+	})
+
+	t.Run("ClaimError", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any(), DefaultIdempotencyTTL).Return(nil, errors.New("database error"))
+
+		// Выполнение
+		_, err := service.CreateOrderIdempotent(authz.WithSystem(context.Background()), userID, key, items, totalPrice)
+
+		// Проверка
+		if err == nil || err.Error() != "failed to claim idempotency key: database error" {
+			t.Errorf("expected claim error, got %v", err)
+		}
+	})
+}
+
+func TestRequestHash(t *testing.T) {
+	items := []entity.OrderItem{{ProductID: 1, Quantity: 2, Price: kzt(5000)}}
+
+	first, _ := requestHash(items, kzt(10000))
+	same, _ := requestHash([]entity.OrderItem{{ProductID: 1, Quantity: 2, Price: kzt(5000)}}, kzt(10000))
+	other, _ := requestHash([]entity.OrderItem{{ProductID: 1, Quantity: 3, Price: kzt(5000)}}, kzt(15000))
+
+	if first != same {
+		t.Errorf("expected equal hashes for equal requests")
+	}
+	if first == other {
+		t.Errorf("expected different hashes for different requests")
+	}
+}
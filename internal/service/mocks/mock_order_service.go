@@ -10,8 +10,10 @@
 package mocks
 
 import (
+	context "context"
 	entity "order_service/internal/entity"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,88 +43,230 @@ func (m *MockOrderServiceInterface) EXPECT() *MockOrderServiceInterfaceMockRecor
 }
 
 // CancelOrder mocks base method.
-func (m *MockOrderServiceInterface) CancelOrder(userID, orderID int64) error {
+func (m *MockOrderServiceInterface) CancelOrder(ctx context.Context, userID, orderID, expectedVersion int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CancelOrder", userID, orderID)
+	ret := m.ctrl.Call(m, "CancelOrder", ctx, userID, orderID, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CancelOrder indicates an expected call of CancelOrder.
-func (mr *MockOrderServiceInterfaceMockRecorder) CancelOrder(userID, orderID any) *gomock.Call {
+func (mr *MockOrderServiceInterfaceMockRecorder) CancelOrder(ctx, userID, orderID, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).CancelOrder), userID, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).CancelOrder), ctx, userID, orderID, expectedVersion)
 }
 
 // CreateOrder mocks base method.
-func (m *MockOrderServiceInterface) CreateOrder(UserID int64, Items []entity.OrderItem, TotalPrice float64) (*entity.PaymentResponse, error) {
+func (m *MockOrderServiceInterface) CreateOrder(ctx context.Context, UserID int64, Items []entity.OrderItem, TotalPrice entity.Money) (*entity.PaymentResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrder", UserID, Items, TotalPrice)
+	ret := m.ctrl.Call(m, "CreateOrder", ctx, UserID, Items, TotalPrice)
 	ret0, _ := ret[0].(*entity.PaymentResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateOrder indicates an expected call of CreateOrder.
-func (mr *MockOrderServiceInterfaceMockRecorder) CreateOrder(UserID, Items, TotalPrice any) *gomock.Call {
+func (mr *MockOrderServiceInterfaceMockRecorder) CreateOrder(ctx, UserID, Items, TotalPrice any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).CreateOrder), UserID, Items, TotalPrice)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).CreateOrder), ctx, UserID, Items, TotalPrice)
+}
+
+// CreateOrderIdempotent mocks base method.
+func (m *MockOrderServiceInterface) CreateOrderIdempotent(ctx context.Context, UserID int64, Key string, Items []entity.OrderItem, TotalPrice entity.Money) (*entity.PaymentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrderIdempotent", ctx, UserID, Key, Items, TotalPrice)
+	ret0, _ := ret[0].(*entity.PaymentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrderIdempotent indicates an expected call of CreateOrderIdempotent.
+func (mr *MockOrderServiceInterfaceMockRecorder) CreateOrderIdempotent(ctx, UserID, Key, Items, TotalPrice any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderIdempotent", reflect.TypeOf((*MockOrderServiceInterface)(nil).CreateOrderIdempotent), ctx, UserID, Key, Items, TotalPrice)
 }
 
 // DeleteOrder mocks base method.
-func (m *MockOrderServiceInterface) DeleteOrder(orderID int64) error {
+func (m *MockOrderServiceInterface) DeleteOrder(ctx context.Context, orderID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteOrder", orderID)
+	ret := m.ctrl.Call(m, "DeleteOrder", ctx, orderID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteOrder indicates an expected call of DeleteOrder.
-func (mr *MockOrderServiceInterfaceMockRecorder) DeleteOrder(orderID any) *gomock.Call {
+func (mr *MockOrderServiceInterfaceMockRecorder) DeleteOrder(ctx, orderID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).DeleteOrder), orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).DeleteOrder), ctx, orderID)
 }
 
 // GetOrderByID mocks base method.
-func (m *MockOrderServiceInterface) GetOrderByID(orderID int64) (*entity.Order, error) {
+func (m *MockOrderServiceInterface) GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderByID", orderID)
+	ret := m.ctrl.Call(m, "GetOrderByID", ctx, orderID)
 	ret0, _ := ret[0].(*entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrderByID indicates an expected call of GetOrderByID.
-func (mr *MockOrderServiceInterfaceMockRecorder) GetOrderByID(orderID any) *gomock.Call {
+func (mr *MockOrderServiceInterfaceMockRecorder) GetOrderByID(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrderByID), ctx, orderID)
+}
+
+// GetOrderByIDIncludingDeleted mocks base method.
+func (m *MockOrderServiceInterface) GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByIDIncludingDeleted", ctx, orderID)
+	ret0, _ := ret[0].(*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByIDIncludingDeleted indicates an expected call of GetOrderByIDIncludingDeleted.
+func (mr *MockOrderServiceInterfaceMockRecorder) GetOrderByIDIncludingDeleted(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByIDIncludingDeleted", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrderByIDIncludingDeleted), ctx, orderID)
+}
+
+// GetOrderHistory mocks base method.
+func (m *MockOrderServiceInterface) GetOrderHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderHistory", ctx, orderID)
+	ret0, _ := ret[0].([]entity.StatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderHistory indicates an expected call of GetOrderHistory.
+func (mr *MockOrderServiceInterfaceMockRecorder) GetOrderHistory(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderHistory", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrderHistory), ctx, orderID)
+}
+
+// GetOrderHistoryIncludingDeleted mocks base method.
+func (m *MockOrderServiceInterface) GetOrderHistoryIncludingDeleted(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderHistoryIncludingDeleted", ctx, orderID)
+	ret0, _ := ret[0].([]entity.StatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderHistoryIncludingDeleted indicates an expected call of GetOrderHistoryIncludingDeleted.
+func (mr *MockOrderServiceInterfaceMockRecorder) GetOrderHistoryIncludingDeleted(ctx, orderID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrderByID), orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderHistoryIncludingDeleted", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrderHistoryIncludingDeleted), ctx, orderID)
 }
 
 // GetOrdersByUserID mocks base method.
-func (m *MockOrderServiceInterface) GetOrdersByUserID(userID int64) ([]entity.Order, error) {
+func (m *MockOrderServiceInterface) GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) (*entity.OrderPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrdersByUserID", userID)
-	ret0, _ := ret[0].([]entity.Order)
+	ret := m.ctrl.Call(m, "GetOrdersByUserID", ctx, query)
+	ret0, _ := ret[0].(*entity.OrderPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrdersByUserID indicates an expected call of GetOrdersByUserID.
-func (mr *MockOrderServiceInterfaceMockRecorder) GetOrdersByUserID(userID any) *gomock.Call {
+func (mr *MockOrderServiceInterfaceMockRecorder) GetOrdersByUserID(ctx, query any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrdersByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockOrderServiceInterface)(nil).GetOrdersByUserID), ctx, query)
+}
+
+// ListOrders mocks base method.
+func (m *MockOrderServiceInterface) ListOrders(ctx context.Context, query entity.OrderQuery) (*entity.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, query)
+	ret0, _ := ret[0].(*entity.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockOrderServiceInterfaceMockRecorder) ListOrders(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrderServiceInterface)(nil).ListOrders), ctx, query)
+}
+
+// RecoverSagas mocks base method.
+func (m *MockOrderServiceInterface) RecoverSagas(ctx context.Context, staleAfter time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoverSagas", ctx, staleAfter)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoverSagas indicates an expected call of RecoverSagas.
+func (mr *MockOrderServiceInterfaceMockRecorder) RecoverSagas(ctx, staleAfter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverSagas", reflect.TypeOf((*MockOrderServiceInterface)(nil).RecoverSagas), ctx, staleAfter)
+}
+
+// RestoreOrder mocks base method.
+func (m *MockOrderServiceInterface) RestoreOrder(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreOrder", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreOrder indicates an expected call of RestoreOrder.
+func (mr *MockOrderServiceInterfaceMockRecorder) RestoreOrder(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreOrder", reflect.TypeOf((*MockOrderServiceInterface)(nil).RestoreOrder), ctx, orderID)
 }
 
 // UpdateOrderStatus mocks base method.
-func (m *MockOrderServiceInterface) UpdateOrderStatus(orderID int64, status string) error {
+func (m *MockOrderServiceInterface) UpdateOrderStatus(ctx context.Context, orderID int64, status entity.OrderStatus, actor, reason string, expectedVersion int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateOrderStatus", orderID, status)
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, orderID, status, actor, reason, expectedVersion)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
-func (mr *MockOrderServiceInterfaceMockRecorder) UpdateOrderStatus(orderID, status any) *gomock.Call {
+func (mr *MockOrderServiceInterfaceMockRecorder) UpdateOrderStatus(ctx, orderID, status, actor, reason, expectedVersion any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockOrderServiceInterface)(nil).UpdateOrderStatus), ctx, orderID, status, actor, reason, expectedVersion)
+}
+
+// MockEventPublisher is a mock of EventPublisher interface.
+type MockEventPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventPublisherMockRecorder
+	isgomock struct{}
+}
+
+// MockEventPublisherMockRecorder is the mock recorder for MockEventPublisher.
+type MockEventPublisherMockRecorder struct {
+	mock *MockEventPublisher
+}
+
+// NewMockEventPublisher creates a new mock instance.
+func NewMockEventPublisher(ctrl *gomock.Controller) *MockEventPublisher {
+	mock := &MockEventPublisher{ctrl: ctrl}
+	mock.recorder = &MockEventPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventPublisher) EXPECT() *MockEventPublisherMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockEventPublisher) Publish(ctx context.Context, event entity.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockEventPublisherMockRecorder) Publish(ctx, event any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockOrderServiceInterface)(nil).UpdateOrderStatus), orderID, status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventPublisher)(nil).Publish), ctx, event)
 }
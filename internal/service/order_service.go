@@ -1,117 +1,288 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"order_service/internal/authz"
 	"order_service/internal/delivery/grpcclient"
+	"order_service/internal/domainerr"
 	"order_service/internal/entity"
+	"order_service/internal/productpb"
 	"order_service/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// DefaultIdempotencyTTL — сколько хранится ключ Idempotency-Key, если не задано иное
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// Размер страницы списка заказов
+const (
+	DefaultOrderPageSize = 20
+	MaxOrderPageSize     = 100
+)
+
+// ErrConcurrentModification возвращается, если заказ изменился после того,
+// как клиент прочитал его версию
+var ErrConcurrentModification = repository.ErrConcurrentModification
+
+// maxUpdateAttempts — сколько раз UpdateOrderStatus перечитывает заказ,
+// если его параллельно изменил другой запрос
+const maxUpdateAttempts = 3
+
 type OrderService struct {
-	repo          repository.OrderRepository
-	productClient grpcclient.ProductServiceClientInterface
-	paymentClient grpcclient.PaymentServiceClientInterface
+	repo           repository.OrderRepository
+	productClient  grpcclient.ProductServiceClientInterface
+	paymentClient  grpcclient.PaymentServiceClientInterface
+	idempotencyTTL time.Duration
+}
+
+// Option настраивает OrderService
+type Option func(*OrderService)
+
+// WithIdempotencyTTL задаёт срок хранения ключей Idempotency-Key
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *OrderService) {
+		if ttl > 0 {
+			s.idempotencyTTL = ttl
+		}
+	}
 }
 
-func NewOrderService(repo repository.OrderRepository, productClient grpcclient.ProductServiceClientInterface, paymentClient grpcclient.PaymentServiceClientInterface) OrderServiceInterface {
-	return &OrderService{
-		repo:          repo,
-		productClient: productClient,
-		paymentClient: paymentClient,
+func NewOrderService(repo repository.OrderRepository, productClient grpcclient.ProductServiceClientInterface, paymentClient grpcclient.PaymentServiceClientInterface, opts ...Option) OrderServiceInterface {
+	s := &OrderService{
+		repo:           repo,
+		productClient:  productClient,
+		paymentClient:  paymentClient,
+		idempotencyTTL: DefaultIdempotencyTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-func (s *OrderService) CreateOrder(userID int64, items []entity.OrderItem, totalPrice float64) (*entity.PaymentResponse, error) {
+func (s *OrderService) CreateOrder(ctx context.Context, userID int64, items []entity.OrderItem, totalPrice entity.Money) (*entity.PaymentResponse, error) {
+	if err := authz.Authorize(ctx, authz.ActionCreate, userID); err != nil {
+		return nil, err
+	}
+
 	// Проверка на дубликаты продуктов
 	seen := make(map[int64]bool)
 	var productIDs []int64
 	for _, item := range items {
 		if seen[item.ProductID] {
-			return nil, fmt.Errorf("duplicate product id: %d", item.ProductID)
+			return nil, domainerr.New(domainerr.DuplicateProduct, "duplicate product id: %d", item.ProductID)
 		}
 		seen[item.ProductID] = true
 		productIDs = append(productIDs, item.ProductID)
 	}
 
-	// Проверка наличия продуктов и их стока
-	stockMap, err := s.productClient.GetProductStock(productIDs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get product stock: %w", err)
-	}
-
-	for index, item := range items {
-		availableStock, exists := stockMap[item.ProductID]
-		if !exists {
-			return nil, fmt.Errorf("product %d not found", item.ProductID)
-		}
-
-		if (availableStock.Stock /*- reservedStock*/) < item.Quantity {
-			return nil, fmt.Errorf("not enough stock for product %d", item.ProductID)
-		}
-		items[index].Name = availableStock.Name
+	state := &entity.OrderSaga{UserID: userID, Step: entity.SagaStepStarted, Status: entity.SagaStatusRunning}
+	if err := s.repo.CreateSaga(ctx, state); err != nil {
+		return nil, fmt.Errorf("failed to start order saga: %w", err)
 	}
 
-	// Создание заказа
-	order := &entity.Order{
-		UserID:     userID,
-		Items:      items,
-		TotalPrice: totalPrice,
-		Status:     "pending",
+	c := &createOrderSaga{
+		service:    s,
+		userID:     userID,
+		items:      items,
+		productIDs: productIDs,
+		totalPrice: totalPrice,
 	}
-	order, err = s.repo.Create(order)
-	if err != nil {
+	sg := &saga{repo: s.repo, state: state, steps: c.steps(state)}
+	if err := sg.run(ctx); err != nil {
 		return nil, err
 	}
 
-	// Генерация ссылки на оплату
-	payment, err := s.paymentClient.GeneratePaymentLink(userID, order.ID, totalPrice)
+	return &entity.PaymentResponse{PaymentURL: state.PaymentURL}, nil
+}
+
+// GetOrderByID возвращает заказ владельцу, поддержке или администратору.
+// Для остальных чужой заказ выглядит как отсутствующий.
+func (u *OrderService) GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error) {
+	order, err := u.repo.GetOrderByID(ctx, orderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get payment link: %w", err)
+		return nil, err
+	}
+	if err := authz.Authorize(ctx, authz.ActionView, order.UserID); err != nil {
+		return nil, err
 	}
+	return order, nil
+}
 
-	return &entity.PaymentResponse{PaymentURL: payment.PaymentUrl}, nil
+// GetOrderByIDIncludingDeleted возвращает заказ, даже если он мягко удалён.
+// Доступен поддержке и администраторам, которые могут восстановить такой заказ.
+func (u *OrderService) GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error) {
+	if err := authz.Authorize(ctx, authz.ActionViewDeleted, 0); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOrderByIDIncludingDeleted(ctx, orderID)
 }
 
-func (u *OrderService) GetOrderByID(orderID int64) (*entity.Order, error) {
-	return u.repo.GetOrderByID(orderID)
+// DeleteOrder мягко удаляет заказ: он пропадает из выборок, но остаётся в базе для учёта.
+// Заказ, который ждёт оплаты, сначала нужно отменить, иначе repository.ErrOrderPending.
+func (u *OrderService) DeleteOrder(ctx context.Context, orderID int64) error {
+	if err := authz.Authorize(ctx, authz.ActionDelete, 0); err != nil {
+		return err
+	}
+	return u.repo.Delete(ctx, orderID)
 }
 
-func (u *OrderService) DeleteOrder(orderID int64) error {
-	return u.repo.Delete(orderID)
+// RestoreOrder восстанавливает мягко удалённый заказ
+func (u *OrderService) RestoreOrder(ctx context.Context, orderID int64) error {
+	if err := authz.Authorize(ctx, authz.ActionRestore, 0); err != nil {
+		return err
+	}
+	return u.repo.Restore(ctx, orderID)
 }
 
-func (u *OrderService) UpdateOrderStatus(orderID int64, status string) error {
-	// Проверяем, допустим ли такой статус
-	validStatuses := map[string]bool{
-		"paid":      true,
-		"shipped":   true,
-		"delivered": true,
-		"canceled":  true,
+// UpdateOrderStatus меняет статус заказа; actor и reason попадают в историю статусов.
+// Если expectedVersion не 0, статус меняется, только пока версия заказа совпадает,
+// иначе ErrConcurrentModification. Без expectedVersion попытка, проигравшая
+// параллельному изменению, повторяется со свежей версией до maxUpdateAttempts раз.
+func (u *OrderService) UpdateOrderStatus(ctx context.Context, orderID int64, status entity.OrderStatus, actor, reason string, expectedVersion int64) error {
+	// Проверяем, известен ли такой статус
+	if !status.Valid() {
+		return domainerr.New(domainerr.InvalidArgument, "unknown order status: %s", status)
 	}
 
-	if !validStatuses[status] {
-		return fmt.Errorf("недопустимый статус: %s", status)
+	// Первое чтение может прийти с отстающей реплики. Тогда запись завершится
+	// конфликтом версий, а повторные чтения пойдут в основную базу.
+	ctx = repository.WithReadYourWrites(ctx)
+
+	var err error
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		err = u.updateOrderStatus(ctx, orderID, status, actor, reason, expectedVersion)
+		// Изменение по версии клиента не повторяется: клиент не видел новую версию
+		if expectedVersion != 0 || !errors.Is(err, ErrConcurrentModification) {
+			return err
+		}
+		logrus.Warnf("Заказ %d изменён параллельно, попытка %d из %d", orderID, attempt, maxUpdateAttempts)
 	}
+	return err
+}
 
+// updateOrderStatus делает одну попытку смены статуса с версией, прочитанной из базы
+func (u *OrderService) updateOrderStatus(ctx context.Context, orderID int64, status entity.OrderStatus, actor, reason string, expectedVersion int64) error {
 	// Получение заказа
-	order, err := u.repo.GetOrderByID(orderID)
+	order, err := u.repo.GetOrderByID(ctx, orderID)
 	if err != nil {
-		return fmt.Errorf("не удалось найти заказ: %w", err)
+		return fmt.Errorf("failed to load order: %w", err)
+	}
+	if err := authz.Authorize(ctx, authz.ActionUpdateStatus, order.UserID); err != nil {
+		return err
+	}
+	if expectedVersion != 0 && order.Version != expectedVersion {
+		return ErrConcurrentModification
+	}
+
+	// Проверяем переход по графу статусов
+	if err := order.Status.TransitionTo(status); err != nil {
+		return err
 	}
 
 	// Обновляем статус заказа
-	order.Status = status
-	if err := u.repo.UpdateOrder(order); err != nil {
-		return fmt.Errorf("ошибка при обновлении заказа: %w", err)
+	change := &entity.StatusChange{
+		OrderID:         orderID,
+		NewStatus:       status,
+		Actor:           actor,
+		Reason:          reason,
+		ExpectedVersion: order.Version,
+	}
+	if err := u.repo.UpdateOrderStatus(ctx, change); err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
 	}
 
 	return nil
 }
 
-func (s *OrderService) GetOrdersByUserID(userID int64) ([]entity.Order, error) {
-	return s.repo.GetOrdersByUserID(userID)
+// GetOrderHistory возвращает историю смены статусов заказа
+func (u *OrderService) GetOrderHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	if _, err := u.GetOrderByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return u.repo.GetStatusHistory(ctx, orderID)
+}
+
+// GetOrderHistoryIncludingDeleted возвращает историю статусов заказа, даже если он
+// мягко удалён: по ней поддержка и администраторы решают, восстанавливать ли заказ
+func (u *OrderService) GetOrderHistoryIncludingDeleted(ctx context.Context, orderID int64) ([]entity.StatusChange, error) {
+	if _, err := u.GetOrderByIDIncludingDeleted(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return u.repo.GetStatusHistory(ctx, orderID)
+}
+
+// GetOrdersByUserID возвращает страницу заказов пользователя. По умолчанию
+// новые заказы идут первыми, размер страницы ограничен MaxOrderPageSize.
+func (s *OrderService) GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) (*entity.OrderPage, error) {
+	// Без пользователя запрос вернул бы чужие заказы
+	if query.UserID == 0 {
+		return nil, domainerr.New(domainerr.InvalidArgument, "user id is required")
+	}
+	if err := authz.Authorize(ctx, authz.ActionList, query.UserID); err != nil {
+		return nil, err
+	}
+	query.IncludeDeleted = false
+	return s.listOrders(ctx, query)
 }
 
-func (s *OrderService) CancelOrder(userID int64, orderID int64) error {
-	return s.repo.CancelOrder(userID, orderID)
+// ListOrders возвращает страницу заказов всех пользователей для администрирования:
+// query.UserID сужает выдачу до одного пользователя, query.IncludeDeleted добавляет удалённые.
+func (s *OrderService) ListOrders(ctx context.Context, query entity.OrderQuery) (*entity.OrderPage, error) {
+	if err := authz.Authorize(ctx, authz.ActionListAll, 0); err != nil {
+		return nil, err
+	}
+	return s.listOrders(ctx, query)
+}
+
+func (s *OrderService) listOrders(ctx context.Context, query entity.OrderQuery) (*entity.OrderPage, error) {
+	if query.Sort == "" {
+		query.Sort = entity.SortDesc
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultOrderPageSize
+	}
+	if query.Limit > MaxOrderPageSize {
+		query.Limit = MaxOrderPageSize
+	}
+
+	// Лишний заказ показывает, есть ли следующая страница
+	pageSize := query.Limit
+	query.Limit++
+	orders, err := s.repo.GetOrdersByUserID(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entity.OrderPage{Orders: orders}
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		page.NextCursor = entity.CursorOf(page.Orders[pageSize-1]).Encode()
+	}
+	if page.Orders == nil {
+		page.Orders = []entity.Order{}
+	}
+	return page, nil
+}
+
+// CancelOrder отменяет заказ пользователя. Если expectedVersion не 0, заказ
+// отменяется, только пока его версия совпадает, иначе ErrConcurrentModification.
+func (s *OrderService) CancelOrder(ctx context.Context, userID int64, orderID int64, expectedVersion int64) error {
+	if err := authz.Authorize(ctx, authz.ActionCancel, userID); err != nil {
+		return err
+	}
+	return s.repo.CancelOrder(ctx, userID, orderID, expectedVersion)
+}
+
+// productPrice возвращает цену товара из ответа Product Service.
+// Старые версии сервиса заполняют только поле price типа double.
+func productPrice(info *productpb.ProductStockInfo) entity.Money {
+	if info.PriceMinor != 0 {
+		return entity.NewMoney(info.PriceMinor, info.Currency)
+	}
+	return entity.MoneyFromFloat(info.Price, info.Currency)
 }
@@ -1,19 +1,45 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"order_service/internal/authz"
 	GrpcMocks "order_service/internal/delivery/grpcclient/mocks"
+	"order_service/internal/domainerr"
 	"order_service/internal/entity"
 	"order_service/internal/paymentpb"
 	"order_service/internal/productpb"
+	"order_service/internal/repository"
 	RepoMocks "order_service/internal/repository/mocks"
 
 	"go.uber.org/mock/gomock"
 )
 
+func kzt(amount int64) entity.Money {
+	return entity.NewMoney(amount, entity.DefaultCurrency)
+}
+
+// expectTx ожидает транзакцию, в которой fn выполняется с тем же контекстом
+func expectTx(mockRepo *RepoMocks.MockOrderRepository) {
+	mockRepo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	})
+}
+
+// expectSaga ожидает запуск саги создания заказа
+func expectSaga(mockRepo *RepoMocks.MockOrderRepository) {
+	mockRepo.EXPECT().CreateSaga(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, saga *entity.OrderSaga) error {
+		if saga.Status != entity.SagaStatusRunning || saga.Step != entity.SagaStepStarted {
+			return errors.New("unexpected initial saga state")
+		}
+		saga.ID = 1
+		return nil
+	})
+}
+
 func TestOrderService_CreateOrder(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -24,40 +50,56 @@ func TestOrderService_CreateOrder(t *testing.T) {
 	service := NewOrderService(mockRepo, mockProductClient, mockPaymentClient)
 
 	items := []entity.OrderItem{
-		{ProductID: 1, Quantity: 2, Price: 50.0},
-		{ProductID: 2, Quantity: 1, Price: 100.0},
+		{ProductID: 1, Quantity: 2, Price: kzt(5000)},
+		{ProductID: 2, Quantity: 1, Price: kzt(10000)},
 	}
 	userID := int64(1)
-	totalPrice := 200.0
+	totalPrice := kzt(20000)
+	noReservations := map[int64]int64{}
+
+	// Последнее сохранённое состояние саги
+	var saved entity.OrderSaga
+	mockRepo.EXPECT().UpdateSaga(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, saga *entity.OrderSaga) error {
+		saved = *saga
+		return nil
+	}).AnyTimes()
 
 	t.Run("Success", func(t *testing.T) {
 		// Подготовка
+		expectSaga(mockRepo)
 		stockMap := map[int64]*productpb.ProductStockInfo{
-			1: {Name: "Product 1", Stock: 10},
-			2: {Name: "Product 2", Stock: 5},
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
 		}
-		mockProductClient.EXPECT().GetProductStock([]int64{1, 2}).Return(stockMap, nil)
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
 
 		expectedOrder := &entity.Order{
 			UserID:     userID,
-			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: 50.0}, {ProductID: 2, Name: "Product 2", Quantity: 1, Price: 100.0}},
+			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: kzt(5000)}, {ProductID: 2, Name: "Product 2", Quantity: 1, Price: kzt(10000)}},
 			TotalPrice: totalPrice,
 			Status:     "pending",
 			CreatedAt:  time.Now().Truncate(time.Second),
 		}
-		mockRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(o *entity.Order) (*entity.Order, error) {
+		expectTx(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *entity.Order) (*entity.Order, error) {
 			o.ID = 1
 			if o.UserID != expectedOrder.UserID || o.TotalPrice != expectedOrder.TotalPrice || o.Status != expectedOrder.Status {
 				t.Errorf("expected order %+v, got %+v", expectedOrder, o)
 			}
+			if o.CreatedAt.IsZero() {
+				t.Errorf("expected CreatedAt to be set")
+			}
 			return o, nil
 		})
+		mockRepo.EXPECT().ReserveStock(gomock.Any(), int64(1), int64(1), int64(2), int64(10)).Return(nil)
+		mockRepo.EXPECT().ReserveStock(gomock.Any(), int64(1), int64(2), int64(1), int64(5)).Return(nil)
 
 		paymentResponse := &paymentpb.PaymentResponse{PaymentUrl: "http://payment.com/link"}
-		mockPaymentClient.EXPECT().GeneratePaymentLink(userID, int64(1), totalPrice).Return(paymentResponse, nil)
+		mockPaymentClient.EXPECT().GeneratePaymentLink(gomock.Any(), userID, int64(1), totalPrice).Return(paymentResponse, nil)
 
 		// Выполнение
-		result, err := service.CreateOrder(userID, items, totalPrice)
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
 
 		// Проверка
 		if err != nil {
@@ -66,17 +108,20 @@ func TestOrderService_CreateOrder(t *testing.T) {
 		if result.PaymentURL != paymentResponse.PaymentUrl {
 			t.Errorf("expected payment URL %v, got %v", paymentResponse.PaymentUrl, result.PaymentURL)
 		}
+		if saved.Status != entity.SagaStatusCompleted || saved.Step != entity.SagaStepRequestPaymentLink || saved.OrderID != 1 {
+			t.Errorf("expected completed saga for order 1, got %+v", saved)
+		}
 	})
 
 	t.Run("DuplicateProductID", func(t *testing.T) {
 		// Подготовка
 		invalidItems := []entity.OrderItem{
-			{ProductID: 1, Quantity: 2, Price: 50.0},
-			{ProductID: 1, Quantity: 1, Price: 50.0},
+			{ProductID: 1, Quantity: 2, Price: kzt(5000)},
+			{ProductID: 1, Quantity: 1, Price: kzt(5000)},
 		}
 
 		// Выполнение
-		result, err := service.CreateOrder(userID, invalidItems, totalPrice)
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, invalidItems, totalPrice)
 
 		// Проверка
 		if err == nil || err.Error() != "duplicate product id: 1" {
@@ -89,34 +134,68 @@ func TestOrderService_CreateOrder(t *testing.T) {
 
 	t.Run("ProductNotFound", func(t *testing.T) {
 		// Подготовка
+		expectSaga(mockRepo)
 		stockMap := map[int64]*productpb.ProductStockInfo{
-			1: {Name: "Product 1", Stock: 10},
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
 			// ProductID 2 отсутствует
 		}
-		mockProductClient.EXPECT().GetProductStock([]int64{1, 2}).Return(stockMap, nil)
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
 
 		// Выполнение
-		result, err := service.CreateOrder(userID, items, totalPrice)
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
 
 		// Проверка
-		if err == nil || err.Error() != "product 2 not found" {
-			t.Errorf("expected error 'product 2 not found', got %v", err)
+		var validation *domainerr.ValidationError
+		if !errors.As(err, &validation) || len(validation.Fields) != 1 || validation.Fields[0].Field != "items[1].product_id" {
+			t.Fatalf("expected field error for items[1].product_id, got %v", err)
 		}
 		if result != nil {
 			t.Errorf("expected nil result, got %v", result)
 		}
+		if saved.Status != entity.SagaStatusCompensated || saved.Step != entity.SagaStepStarted || saved.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
 	})
 
 	t.Run("NotEnoughStock", func(t *testing.T) {
 		// Подготовка
+		expectSaga(mockRepo)
+		stockMap := map[int64]*productpb.ProductStockInfo{
+			1: {Name: "Product 1", Stock: 1, PriceMinor: 5000, Currency: "KZT"}, // Недостаточно для 2
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
+		}
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
+
+		// Выполнение
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
+
+		// Проверка
+		if err == nil || err.Error() != "not enough stock for product 1" {
+			t.Errorf("expected error 'not enough stock for product 1', got %v", err)
+		}
+		if result != nil {
+			t.Errorf("expected nil result, got %v", result)
+		}
+		if saved.Status != entity.SagaStatusCompensated || saved.Step != entity.SagaStepStarted || saved.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
+	})
+
+	t.Run("ReservedStockIsUnavailable", func(t *testing.T) {
+		// Подготовка
+		expectSaga(mockRepo)
 		stockMap := map[int64]*productpb.ProductStockInfo{
-			1: {Name: "Product 1", Stock: 1}, // Недостаточно для 2
-			2: {Name: "Product 2", Stock: 5},
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
 		}
-		mockProductClient.EXPECT().GetProductStock([]int64{1, 2}).Return(stockMap, nil)
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		// 9 из 10 единиц товара 1 уже зарезервированы другими заказами
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(map[int64]int64{1: 9}, nil)
 
 		// Выполнение
-		result, err := service.CreateOrder(userID, items, totalPrice)
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
 
 		// Проверка
 		if err == nil || err.Error() != "not enough stock for product 1" {
@@ -125,19 +204,123 @@ func TestOrderService_CreateOrder(t *testing.T) {
 		if result != nil {
 			t.Errorf("expected nil result, got %v", result)
 		}
+		if saved.Status != entity.SagaStatusCompensated || saved.Step != entity.SagaStepStarted || saved.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
+	})
+
+	t.Run("ReservationLostRace", func(t *testing.T) {
+		// Подготовка
+		expectSaga(mockRepo)
+		stockMap := map[int64]*productpb.ProductStockInfo{
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
+		}
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
+
+		expectTx(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *entity.Order) (*entity.Order, error) {
+			o.ID = 1
+			return o, nil
+		})
+		// Параллельный заказ успел зарезервировать остаток между проверкой и транзакцией,
+		// поэтому сохранённый заказ откатывается вместе с транзакцией
+		mockRepo.EXPECT().ReserveStock(gomock.Any(), int64(1), int64(1), int64(2), int64(10)).Return(repository.ErrInsufficientStock)
+
+		// Выполнение
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
+
+		// Проверка
+		if err == nil || err.Error() != "not enough stock for product 1" {
+			t.Errorf("expected error 'not enough stock for product 1', got %v", err)
+		}
+		if result != nil {
+			t.Errorf("expected nil result, got %v", result)
+		}
+		if saved.Status != entity.SagaStatusCompensated || saved.Step != entity.SagaStepValidateStock || saved.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
+	})
+
+	t.Run("ClientPriceIgnored", func(t *testing.T) {
+		// Подготовка
+		expectSaga(mockRepo)
+		cheapItems := []entity.OrderItem{
+			{ProductID: 1, Quantity: 2, Price: kzt(50)},
+			{ProductID: 2, Quantity: 1, Price: kzt(100)},
+		}
+		stockMap := map[int64]*productpb.ProductStockInfo{
+			// Старая версия Product Service заполняет только price типа double
+			1: {Name: "Product 1", Stock: 10, Price: 50.0},
+			2: {Name: "Product 2", Stock: 5, Price: 100.0},
+		}
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
+
+		expectTx(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *entity.Order) (*entity.Order, error) {
+			o.ID = 1
+			if o.Items[0].Price != kzt(5000) || o.Items[1].Price != kzt(10000) || o.TotalPrice != totalPrice {
+				t.Errorf("expected server-side prices, got %+v", o)
+			}
+			return o, nil
+		})
+		mockRepo.EXPECT().ReserveStock(gomock.Any(), int64(1), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		paymentResponse := &paymentpb.PaymentResponse{PaymentUrl: "http://payment.com/link"}
+		mockPaymentClient.EXPECT().GeneratePaymentLink(gomock.Any(), userID, int64(1), totalPrice).Return(paymentResponse, nil)
+
+		// Выполнение
+		_, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, cheapItems, totalPrice)
+
+		// Проверка
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if saved.Status != entity.SagaStatusCompleted || saved.Step != entity.SagaStepRequestPaymentLink || saved.OrderID != 1 {
+			t.Errorf("expected completed saga for order 1, got %+v", saved)
+		}
+	})
+
+	t.Run("TotalPriceMismatch", func(t *testing.T) {
+		// Подготовка
+		expectSaga(mockRepo)
+		stockMap := map[int64]*productpb.ProductStockInfo{
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
+		}
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
+
+		// Выполнение
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, kzt(100))
+
+		// Проверка
+		if err == nil || err.Error() != "total price mismatch: expected 200.00 KZT, got 1.00 KZT" {
+			t.Errorf("expected total price mismatch error, got %v", err)
+		}
+		if result != nil {
+			t.Errorf("expected nil result, got %v", result)
+		}
+		if saved.Status != entity.SagaStatusCompensated || saved.Step != entity.SagaStepStarted || saved.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
 	})
 
 	t.Run("RepositoryError", func(t *testing.T) {
 		// Подготовка
+		expectSaga(mockRepo)
 		stockMap := map[int64]*productpb.ProductStockInfo{
-			1: {Name: "Product 1", Stock: 10},
-			2: {Name: "Product 2", Stock: 5},
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
 		}
-		mockProductClient.EXPECT().GetProductStock([]int64{1, 2}).Return(stockMap, nil)
-		mockRepo.EXPECT().Create(gomock.Any()).Return(nil, errors.New("database error"))
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
+		expectTx(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
 
 		// Выполнение
-		result, err := service.CreateOrder(userID, items, totalPrice)
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
 
 		// Проверка
 		if err == nil || err.Error() != "database error" {
@@ -146,23 +329,38 @@ func TestOrderService_CreateOrder(t *testing.T) {
 		if result != nil {
 			t.Errorf("expected nil result, got %v", result)
 		}
+		if saved.Status != entity.SagaStatusCompensated || saved.Step != entity.SagaStepValidateStock || saved.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
 	})
 
 	t.Run("PaymentServiceError", func(t *testing.T) {
 		// Подготовка
+		expectSaga(mockRepo)
 		stockMap := map[int64]*productpb.ProductStockInfo{
-			1: {Name: "Product 1", Stock: 10},
-			2: {Name: "Product 2", Stock: 5},
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
 		}
-		mockProductClient.EXPECT().GetProductStock([]int64{1, 2}).Return(stockMap, nil)
-		mockRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(o *entity.Order) (*entity.Order, error) {
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
+		expectTx(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *entity.Order) (*entity.Order, error) {
 			o.ID = 1
 			return o, nil
 		})
-		mockPaymentClient.EXPECT().GeneratePaymentLink(userID, int64(1), totalPrice).Return(nil, errors.New("payment service error"))
+		mockRepo.EXPECT().ReserveStock(gomock.Any(), int64(1), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockPaymentClient.EXPECT().GeneratePaymentLink(gomock.Any(), userID, int64(1), totalPrice).Return(nil, errors.New("payment service error"))
+		// Компенсация: резервы снимаются, заказ переводится в failed
+		mockRepo.EXPECT().ReleaseReservations(gomock.Any(), int64(1)).Return(nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, change *entity.StatusChange) error {
+			if change.OrderID != 1 || change.NewStatus != entity.OrderStatusFailed || change.Actor != "system:create-order-saga" {
+				t.Errorf("unexpected status change %+v", change)
+			}
+			return nil
+		})
 
 		// Выполнение
-		result, err := service.CreateOrder(userID, items, totalPrice)
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
 
 		// Проверка
 		if err == nil || err.Error() != "failed to get payment link: payment service error" {
@@ -171,6 +369,320 @@ func TestOrderService_CreateOrder(t *testing.T) {
 		if result != nil {
 			t.Errorf("expected nil result, got %v", result)
 		}
+		if saved.Status != entity.SagaStatusCompensated || saved.Step != entity.SagaStepPersistOrder || saved.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
+	})
+
+	t.Run("ClientDisconnected", func(t *testing.T) {
+		// Подготовка
+		ctx, cancel := context.WithCancel(authz.WithSystem(context.Background()))
+		defer cancel()
+		expectSaga(mockRepo)
+		stockMap := map[int64]*productpb.ProductStockInfo{
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
+		}
+		mockProductClient.EXPECT().GetProductStock(ctx, []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(ctx, []int64{1, 2}).Return(noReservations, nil)
+		expectTx(mockRepo)
+		mockRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, o *entity.Order) (*entity.Order, error) {
+			o.ID = 1
+			return o, nil
+		})
+		mockRepo.EXPECT().ReserveStock(ctx, int64(1), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		// Клиент отключается, пока ждём Payment Service
+		mockPaymentClient.EXPECT().GeneratePaymentLink(ctx, userID, int64(1), totalPrice).DoAndReturn(
+			func(ctx context.Context, _, _ int64, _ entity.Money) (*paymentpb.PaymentResponse, error) {
+				cancel()
+				return nil, ctx.Err()
+			})
+		// Компенсация выполняется с контекстом, который уже не отменяется
+		mockRepo.EXPECT().ReleaseReservations(gomock.Any(), int64(1)).DoAndReturn(func(ctx context.Context, _ int64) error {
+			return ctx.Err()
+		})
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ *entity.StatusChange) error {
+			return ctx.Err()
+		})
+
+		// Выполнение
+		_, err := service.CreateOrder(ctx, userID, items, totalPrice)
+
+		// Проверка
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if saved.Status != entity.SagaStatusCompensated {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
+	})
+
+	t.Run("PaidBeforeCompensation", func(t *testing.T) {
+		// Подготовка
+		expectSaga(mockRepo)
+		stockMap := map[int64]*productpb.ProductStockInfo{
+			1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+			2: {Name: "Product 2", Stock: 5, PriceMinor: 10000, Currency: "KZT"},
+		}
+		mockProductClient.EXPECT().GetProductStock(gomock.Any(), []int64{1, 2}).Return(stockMap, nil)
+		mockRepo.EXPECT().GetReservedStock(gomock.Any(), []int64{1, 2}).Return(noReservations, nil)
+		expectTx(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *entity.Order) (*entity.Order, error) {
+			o.ID = 1
+			return o, nil
+		})
+		mockRepo.EXPECT().ReserveStock(gomock.Any(), int64(1), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockPaymentClient.EXPECT().GeneratePaymentLink(gomock.Any(), userID, int64(1), totalPrice).Return(nil, errors.New("timeout"))
+		mockRepo.EXPECT().ReleaseReservations(gomock.Any(), int64(1)).Return(nil)
+		// Ссылка всё же была выдана и заказ успели оплатить — откатывать его нельзя
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(
+			&entity.TransitionError{From: entity.OrderStatusPaid, To: entity.OrderStatusFailed})
+
+		// Выполнение
+		_, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
+
+		// Проверка
+		if err == nil || err.Error() != "failed to get payment link: timeout" {
+			t.Errorf("expected payment link error, got %v", err)
+		}
+		if saved.Status != entity.SagaStatusCompensated {
+			t.Errorf("expected compensated saga, got %+v", saved)
+		}
+	})
+
+	t.Run("SagaStartError", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().CreateSaga(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+
+		// Выполнение
+		result, err := service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
+
+		// Проверка
+		if err == nil || err.Error() != "failed to start order saga: database error" {
+			t.Errorf("expected saga start error, got %v", err)
+		}
+		if result != nil {
+			t.Errorf("expected nil result, got %v", result)
+		}
+	})
+}
+
+// sagaStateFixture — сервис с моками для проверки сохранения шагов саги создания заказа
+type sagaStateFixture struct {
+	repo    *RepoMocks.MockOrderRepository
+	payment *GrpcMocks.MockPaymentServiceClientInterface
+	service OrderServiceInterface
+	saved   []entity.OrderSaga // успешно сохранённые состояния саги
+	inTx    bool
+}
+
+// newSagaStateFixture ожидает запуск саги и проверку остатков; сохранение шага failStep завершается ошибкой failErr
+func newSagaStateFixture(t *testing.T, failStep entity.SagaStep, failErr error) *sagaStateFixture {
+	ctrl := gomock.NewController(t)
+	productClient := GrpcMocks.NewMockProductServiceClientInterface(ctrl)
+	f := &sagaStateFixture{
+		repo:    RepoMocks.NewMockOrderRepository(ctrl),
+		payment: GrpcMocks.NewMockPaymentServiceClientInterface(ctrl),
+	}
+	f.service = NewOrderService(f.repo, productClient, f.payment)
+
+	expectSaga(f.repo)
+	productClient.EXPECT().GetProductStock(gomock.Any(), []int64{1}).Return(map[int64]*productpb.ProductStockInfo{
+		1: {Name: "Product 1", Stock: 10, PriceMinor: 5000, Currency: "KZT"},
+	}, nil)
+	f.repo.EXPECT().GetReservedStock(gomock.Any(), []int64{1}).Return(map[int64]int64{}, nil)
+	f.repo.EXPECT().UpdateSaga(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, saga *entity.OrderSaga) error {
+		if saga.Status == entity.SagaStatusRunning && saga.Step == entity.SagaStepPersistOrder && !f.inTx {
+			t.Errorf("expected persist_order step to be saved inside the order transaction")
+		}
+		if saga.Status == entity.SagaStatusRunning && saga.Step == failStep {
+			return failErr
+		}
+		f.saved = append(f.saved, *saga)
+		return nil
+	}).AnyTimes()
+	return f
+}
+
+// expectOrderTx ожидает транзакцию, в которой создаётся заказ 1 и резервируется его товар
+func (f *sagaStateFixture) expectOrderTx() {
+	f.repo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		f.inTx = true
+		defer func() { f.inTx = false }()
+		return fn(ctx)
+	})
+	f.repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *entity.Order) (*entity.Order, error) {
+		o.ID = 1
+		return o, nil
+	})
+	f.repo.EXPECT().ReserveStock(gomock.Any(), int64(1), int64(1), int64(2), int64(10)).Return(nil)
+}
+
+func (f *sagaStateFixture) last() entity.OrderSaga {
+	return f.saved[len(f.saved)-1]
+}
+
+func TestOrderService_CreateOrderSagaState(t *testing.T) {
+	items := []entity.OrderItem{{ProductID: 1, Quantity: 2}}
+	userID := int64(1)
+	totalPrice := kzt(10000)
+
+	t.Run("OrderIDSavedWithOrder", func(t *testing.T) {
+		// Подготовка
+		f := newSagaStateFixture(t, "", nil)
+		f.expectOrderTx()
+		f.payment.EXPECT().GeneratePaymentLink(gomock.Any(), userID, int64(1), totalPrice).
+			Return(&paymentpb.PaymentResponse{PaymentUrl: "http://payment.com/link"}, nil)
+
+		// Выполнение
+		_, err := f.service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
+
+		// Проверка
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var persisted bool
+		for _, saga := range f.saved {
+			if saga.Step == entity.SagaStepPersistOrder && saga.OrderID == 1 {
+				persisted = true
+			}
+		}
+		if !persisted {
+			t.Errorf("expected persist_order step with order 1, got %+v", f.saved)
+		}
+	})
+
+	t.Run("StepNotSaved", func(t *testing.T) {
+		// Подготовка: шаг validate_stock не сохранился, заказ не создаётся
+		f := newSagaStateFixture(t, entity.SagaStepValidateStock, errors.New("database error"))
+
+		// Выполнение
+		result, err := f.service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
+
+		// Проверка
+		if result != nil || err == nil || err.Error() != "failed to save saga step validate_stock: database error" {
+			t.Fatalf("expected saga step error, got %v, %v", result, err)
+		}
+		if last := f.last(); last.Status != entity.SagaStatusCompensated || last.Error != err.Error() {
+			t.Errorf("expected compensated saga, got %+v", last)
+		}
+	})
+
+	t.Run("PersistStepNotSaved", func(t *testing.T) {
+		// Подготовка: шаг не сохранился — транзакция с заказом откатывается, компенсировать нечего
+		f := newSagaStateFixture(t, entity.SagaStepPersistOrder, errors.New("database error"))
+		f.expectOrderTx()
+
+		// Выполнение
+		result, err := f.service.CreateOrder(authz.WithSystem(context.Background()), userID, items, totalPrice)
+
+		// Проверка
+		if result != nil || err == nil || err.Error() != "failed to save saga step persist_order: database error" {
+			t.Fatalf("expected saga step error, got %v, %v", result, err)
+		}
+		if last := f.last(); last.Status != entity.SagaStatusCompensated || last.OrderID != 0 {
+			t.Errorf("expected compensated saga without order, got %+v", last)
+		}
+	})
+}
+
+func TestOrderService_RecoverSagas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	service := NewOrderService(mockRepo, nil, nil)
+
+	t.Run("CompletesSagaWithPaymentLink", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetStaleSagas(gomock.Any(), time.Minute).Return([]entity.OrderSaga{
+			{ID: 1, OrderID: 10, Step: entity.SagaStepRequestPaymentLink, Status: entity.SagaStatusRunning},
+		}, nil)
+		mockRepo.EXPECT().UpdateSaga(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, saga *entity.OrderSaga) error {
+			if saga.Status != entity.SagaStatusCompleted {
+				t.Errorf("expected completed saga, got %+v", saga)
+			}
+			return nil
+		})
+
+		// Выполнение
+		recovered, err := service.RecoverSagas(authz.WithSystem(context.Background()), time.Minute)
+
+		// Проверка
+		if err != nil || recovered != 1 {
+			t.Errorf("expected 1 recovered saga, got %d, %v", recovered, err)
+		}
+	})
+
+	t.Run("CompensatesInterruptedSaga", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetStaleSagas(gomock.Any(), time.Minute).Return([]entity.OrderSaga{
+			{ID: 1, OrderID: 10, Step: entity.SagaStepPersistOrder, Status: entity.SagaStatusRunning},
+			{ID: 2, Step: entity.SagaStepStarted, Status: entity.SagaStatusRunning},
+		}, nil)
+		var statuses []entity.SagaStatus
+		mockRepo.EXPECT().UpdateSaga(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, saga *entity.OrderSaga) error {
+			statuses = append(statuses, saga.Status)
+			return nil
+		}).Times(4)
+		mockRepo.EXPECT().ReleaseReservations(gomock.Any(), int64(10)).Return(nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, change *entity.StatusChange) error {
+			if change.OrderID != 10 || change.NewStatus != entity.OrderStatusFailed {
+				t.Errorf("unexpected status change %+v", change)
+			}
+			return nil
+		})
+
+		// Выполнение
+		recovered, err := service.RecoverSagas(authz.WithSystem(context.Background()), time.Minute)
+
+		// Проверка
+		if err != nil || recovered != 2 {
+			t.Errorf("expected 2 recovered sagas, got %d, %v", recovered, err)
+		}
+		expected := []entity.SagaStatus{
+			entity.SagaStatusCompensating, entity.SagaStatusCompensated,
+			entity.SagaStatusCompensating, entity.SagaStatusCompensated,
+		}
+		if len(statuses) != len(expected) {
+			t.Fatalf("expected statuses %v, got %v", expected, statuses)
+		}
+		for i := range expected {
+			if statuses[i] != expected[i] {
+				t.Errorf("expected statuses %v, got %v", expected, statuses)
+				break
+			}
+		}
+	})
+
+	t.Run("SkipsSagaThatFailsToRecover", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetStaleSagas(gomock.Any(), time.Minute).Return([]entity.OrderSaga{
+			{ID: 1, OrderID: 10, Step: entity.SagaStepPersistOrder, Status: entity.SagaStatusCompensating},
+		}, nil)
+		mockRepo.EXPECT().UpdateSaga(gomock.Any(), gomock.Any()).Return(nil)
+		mockRepo.EXPECT().ReleaseReservations(gomock.Any(), int64(10)).Return(errors.New("database error"))
+
+		// Выполнение
+		recovered, err := service.RecoverSagas(authz.WithSystem(context.Background()), time.Minute)
+
+		// Проверка
+		if err != nil || recovered != 0 {
+			t.Errorf("expected 0 recovered sagas, got %d, %v", recovered, err)
+		}
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetStaleSagas(gomock.Any(), time.Minute).Return(nil, errors.New("database error"))
+
+		// Выполнение
+		_, err := service.RecoverSagas(authz.WithSystem(context.Background()), time.Minute)
+
+		// Проверка
+		if err == nil || err.Error() != "failed to load unfinished sagas: database error" {
+			t.Errorf("expected error, got %v", err)
+		}
 	})
 }
 
@@ -188,15 +700,15 @@ func TestOrderService_GetOrderByID(t *testing.T) {
 		expectedOrder := &entity.Order{
 			ID:         1,
 			UserID:     1,
-			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: 50.0}},
-			TotalPrice: 100.0,
+			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: kzt(5000)}},
+			TotalPrice: kzt(10000),
 			Status:     "pending",
 			CreatedAt:  time.Now().Truncate(time.Second),
 		}
-		mockRepo.EXPECT().GetOrderByID(int64(1)).Return(expectedOrder, nil)
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(expectedOrder, nil)
 
 		// Выполнение
-		order, err := service.GetOrderByID(1)
+		order, err := service.GetOrderByID(authz.WithSystem(context.Background()), 1)
 
 		// Проверка
 		if err != nil {
@@ -209,10 +721,10 @@ func TestOrderService_GetOrderByID(t *testing.T) {
 
 	t.Run("NotFound", func(t *testing.T) {
 		// Подготовка
-		mockRepo.EXPECT().GetOrderByID(int64(999)).Return(nil, errors.New("order not found"))
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(999)).Return(nil, errors.New("order not found"))
 
 		// Выполнение
-		order, err := service.GetOrderByID(999)
+		order, err := service.GetOrderByID(authz.WithSystem(context.Background()), 999)
 
 		// Проверка
 		if err == nil || err.Error() != "order not found" {
@@ -238,15 +750,20 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 		order := &entity.Order{
 			ID:         1,
 			UserID:     1,
-			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: 50.0}},
-			TotalPrice: 100.0,
+			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: kzt(5000)}},
+			TotalPrice: kzt(10000),
 			Status:     "pending",
 		}
-		mockRepo.EXPECT().GetOrderByID(int64(1)).Return(order, nil)
-		mockRepo.EXPECT().UpdateOrder(gomock.Any()).Return(nil)
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(order, nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *entity.StatusChange) error {
+			if c.OrderID != 1 || c.NewStatus != entity.OrderStatusPaid || c.Actor != "kafka:payment_events" {
+				t.Errorf("unexpected status change %+v", c)
+			}
+			return nil
+		})
 
 		// Выполнение
-		err := service.UpdateOrderStatus(1, "paid")
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 1, "paid", "kafka:payment_events", "", 0)
 
 		// Проверка
 		if err != nil {
@@ -254,26 +771,95 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 		}
 	})
 
+	t.Run("RetryOnConcurrentModification", func(t *testing.T) {
+		// Подготовка: между чтением и записью заказ успел смениться
+		gomock.InOrder(
+			mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&entity.Order{ID: 1, Status: entity.OrderStatusPending, Version: 1}, nil),
+			mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(ErrConcurrentModification),
+			mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&entity.Order{ID: 1, Status: entity.OrderStatusPending, Version: 2}, nil),
+			mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *entity.StatusChange) error {
+				if c.ExpectedVersion != 2 {
+					t.Errorf("expected retry with version 2, got %d", c.ExpectedVersion)
+				}
+				return nil
+			}),
+		)
+
+		// Выполнение
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 1, entity.OrderStatusPaid, "kafka:payment_events", "", 0)
+
+		// Проверка
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("RetriesExhausted", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&entity.Order{ID: 1, Status: entity.OrderStatusPending, Version: 1}, nil).Times(maxUpdateAttempts)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(ErrConcurrentModification).Times(maxUpdateAttempts)
+
+		// Выполнение
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 1, entity.OrderStatusPaid, "kafka:payment_events", "", 0)
+
+		// Проверка
+		if !errors.Is(err, ErrConcurrentModification) {
+			t.Errorf("expected ErrConcurrentModification, got %v", err)
+		}
+	})
+
+	t.Run("StaleExpectedVersion", func(t *testing.T) {
+		// Подготовка: клиент видел версию 1, а в базе уже 2; повтора нет
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&entity.Order{ID: 1, Status: entity.OrderStatusPending, Version: 2}, nil)
+
+		// Выполнение
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 1, entity.OrderStatusPaid, "admin:101", "вручную", 1)
+
+		// Проверка
+		if !errors.Is(err, ErrConcurrentModification) {
+			t.Errorf("expected ErrConcurrentModification, got %v", err)
+		}
+	})
+
 	t.Run("InvalidStatus", func(t *testing.T) {
 		// Выполнение
-		err := service.UpdateOrderStatus(1, "invalid")
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 1, "invalid", "kafka:payment_events", "", 0)
 
 		// Проверка
-		if err == nil || err.Error() != "недопустимый статус: invalid" {
-			t.Errorf("expected error 'недопустимый статус: invalid', got %v", err)
+		if !errors.Is(err, domainerr.InvalidArgument) || err.Error() != "unknown order status: invalid" {
+			t.Errorf("expected error 'unknown order status: invalid', got %v", err)
+		}
+	})
+
+	t.Run("InvalidTransition", func(t *testing.T) {
+		// Подготовка
+		order := &entity.Order{
+			ID:         1,
+			UserID:     1,
+			TotalPrice: kzt(10000),
+			Status:     entity.OrderStatusDelivered,
+		}
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(order, nil)
+
+		// Выполнение
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 1, entity.OrderStatusPaid, "kafka:payment_events", "", 0)
+
+		// Проверка
+		if !errors.Is(err, entity.ErrInvalidTransition) {
+			t.Errorf("expected ErrInvalidTransition, got %v", err)
 		}
 	})
 
 	t.Run("OrderNotFound", func(t *testing.T) {
 		// Подготовка
-		mockRepo.EXPECT().GetOrderByID(int64(999)).Return(nil, errors.New("order not found"))
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(999)).Return(nil, errors.New("order not found"))
 
 		// Выполнение
-		err := service.UpdateOrderStatus(999, "paid")
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 999, "paid", "kafka:payment_events", "", 0)
 
 		// Проверка
-		if err == nil || err.Error() != "не удалось найти заказ: order not found" {
-			t.Errorf("expected error 'не удалось найти заказ: order not found', got %v", err)
+		if err == nil || err.Error() != "failed to load order: order not found" {
+			t.Errorf("expected error 'failed to load order: order not found', got %v", err)
 		}
 	})
 
@@ -282,19 +868,106 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 		order := &entity.Order{
 			ID:         1,
 			UserID:     1,
-			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: 50.0}},
-			TotalPrice: 100.0,
+			Items:      []entity.OrderItem{{ProductID: 1, Name: "Product 1", Quantity: 2, Price: kzt(5000)}},
+			TotalPrice: kzt(10000),
 			Status:     "pending",
 		}
-		mockRepo.EXPECT().GetOrderByID(int64(1)).Return(order, nil)
-		mockRepo.EXPECT().UpdateOrder(gomock.Any()).Return(errors.New("database error"))
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(order, nil)
+		mockRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
 
 		// Выполнение
-		err := service.UpdateOrderStatus(1, "paid")
+		err := service.UpdateOrderStatus(authz.WithSystem(context.Background()), 1, "paid", "kafka:payment_events", "", 0)
 
 		// Проверка
-		if err == nil || err.Error() != "ошибка при обновлении заказа: database error" {
-			t.Errorf("expected error 'ошибка при обновлении заказа: database error', got %v", err)
+		if err == nil || err.Error() != "failed to update order: database error" {
+			t.Errorf("expected error 'failed to update order: database error', got %v", err)
+		}
+	})
+}
+
+func TestOrderService_GetOrderHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockProductClient := GrpcMocks.NewMockProductServiceClientInterface(ctrl)
+	mockPaymentClient := GrpcMocks.NewMockPaymentServiceClientInterface(ctrl)
+	service := NewOrderService(mockRepo, mockProductClient, mockPaymentClient)
+
+	t.Run("Success", func(t *testing.T) {
+		// Подготовка
+		history := []entity.StatusChange{
+			{ID: 1, OrderID: 1, OldStatus: entity.OrderStatusPending, NewStatus: entity.OrderStatusPaid, Actor: "kafka:payment_events"},
+			{ID: 2, OrderID: 1, OldStatus: entity.OrderStatusPaid, NewStatus: entity.OrderStatusShipped, Actor: "admin:101", Reason: "handed to courier"},
+		}
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&entity.Order{ID: 1, Status: entity.OrderStatusShipped}, nil)
+		mockRepo.EXPECT().GetStatusHistory(gomock.Any(), int64(1)).Return(history, nil)
+
+		// Выполнение
+		result, err := service.GetOrderHistory(authz.WithSystem(context.Background()), 1)
+
+		// Проверка
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if len(result) != 2 || result[1].Reason != "handed to courier" {
+			t.Errorf("expected history %v, got %v", history, result)
+		}
+	})
+
+	t.Run("OrderNotFound", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(999)).Return(nil, errors.New("order not found"))
+
+		// Выполнение
+		result, err := service.GetOrderHistory(authz.WithSystem(context.Background()), 999)
+
+		// Проверка
+		if err == nil || err.Error() != "order not found" {
+			t.Errorf("expected error 'order not found', got %v", err)
+		}
+		if result != nil {
+			t.Errorf("expected nil history, got %v", result)
+		}
+	})
+}
+
+func TestOrderService_GetOrderHistoryIncludingDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockProductClient := GrpcMocks.NewMockProductServiceClientInterface(ctrl)
+	mockPaymentClient := GrpcMocks.NewMockPaymentServiceClientInterface(ctrl)
+	service := NewOrderService(mockRepo, mockProductClient, mockPaymentClient)
+
+	t.Run("DeletedOrder", func(t *testing.T) {
+		// Подготовка
+		ctx := authz.WithPrincipal(context.Background(), authz.Principal{UserID: 100, Role: entity.RoleSupport})
+		deletedAt := time.Now().UTC()
+		history := []entity.StatusChange{{ID: 1, OrderID: 1, OldStatus: entity.OrderStatusPending, NewStatus: entity.OrderStatusCanceled, Actor: "user:7"}}
+		mockRepo.EXPECT().GetOrderByIDIncludingDeleted(gomock.Any(), int64(1)).Return(&entity.Order{ID: 1, UserID: 7, Status: entity.OrderStatusCanceled, DeletedAt: &deletedAt}, nil)
+		mockRepo.EXPECT().GetStatusHistory(gomock.Any(), int64(1)).Return(history, nil)
+
+		// Выполнение
+		result, err := service.GetOrderHistoryIncludingDeleted(ctx, 1)
+
+		// Проверка
+		if err != nil || len(result) != 1 || result[0].NewStatus != entity.OrderStatusCanceled {
+			t.Errorf("expected history of the deleted order, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("UserDenied", func(t *testing.T) {
+		// Подготовка
+		ctx := authz.WithPrincipal(context.Background(), authz.Principal{UserID: 7})
+
+		// Выполнение
+		result, err := service.GetOrderHistoryIncludingDeleted(ctx, 1)
+
+		// Проверка
+		if !errors.Is(err, authz.ErrForbidden) || result != nil {
+			t.Errorf("expected ErrForbidden, got %v, %v", result, err)
 		}
 	})
 }
@@ -311,36 +984,218 @@ func TestOrderService_GetOrdersByUserID(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		// Подготовка
 		expectedOrders := []entity.Order{
-			{ID: 1, UserID: 1, TotalPrice: 100.0, Status: "pending"},
-			{ID: 2, UserID: 1, TotalPrice: 200.0, Status: "paid"},
+			{ID: 2, UserID: 1, TotalPrice: kzt(20000), Status: "paid"},
+			{ID: 1, UserID: 1, TotalPrice: kzt(10000), Status: "pending"},
 		}
-		mockRepo.EXPECT().GetOrdersByUserID(int64(1)).Return(expectedOrders, nil)
+		// По умолчанию новые заказы первыми и на один больше размера страницы
+		expectedQuery := entity.OrderQuery{UserID: 1, Sort: entity.SortDesc, Limit: DefaultOrderPageSize + 1}
+		mockRepo.EXPECT().GetOrdersByUserID(gomock.Any(), expectedQuery).Return(expectedOrders, nil)
 
 		// Выполнение
-		orders, err := service.GetOrdersByUserID(1)
+		page, err := service.GetOrdersByUserID(authz.WithSystem(context.Background()), entity.OrderQuery{UserID: 1})
 
 		// Проверка
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
-		if len(orders) != 2 {
-			t.Errorf("expected 2 orders, got %d", len(orders))
+		if len(page.Orders) != 2 || page.NextCursor != "" {
+			t.Errorf("expected 2 orders without next cursor, got %+v", page)
+		}
+	})
+
+	t.Run("NextPage", func(t *testing.T) {
+		// Подготовка
+		createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		expectedOrders := []entity.Order{
+			{ID: 1, UserID: 1, CreatedAt: createdAt},
+			{ID: 2, UserID: 1, CreatedAt: createdAt},
+			{ID: 3, UserID: 1, CreatedAt: createdAt},
+		}
+		expectedQuery := entity.OrderQuery{UserID: 1, Sort: entity.SortAsc, Limit: 3}
+		mockRepo.EXPECT().GetOrdersByUserID(gomock.Any(), expectedQuery).Return(expectedOrders, nil)
+
+		// Выполнение
+		page, err := service.GetOrdersByUserID(authz.WithSystem(context.Background()), entity.OrderQuery{UserID: 1, Sort: entity.SortAsc, Limit: 2})
+
+		// Проверка
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(page.Orders) != 2 {
+			t.Errorf("expected 2 orders, got %d", len(page.Orders))
+		}
+		cursor, err := entity.DecodeOrderCursor(page.NextCursor)
+		if err != nil || cursor.ID != 2 || !cursor.CreatedAt.Equal(createdAt) {
+			t.Errorf("expected cursor after order 2, got %+v, %v", cursor, err)
+		}
+	})
+
+	t.Run("LimitCapped", func(t *testing.T) {
+		// Подготовка
+		expectedQuery := entity.OrderQuery{UserID: 1, Sort: entity.SortDesc, Limit: MaxOrderPageSize + 1}
+		mockRepo.EXPECT().GetOrdersByUserID(gomock.Any(), expectedQuery).Return(nil, nil)
+
+		// Выполнение
+		page, err := service.GetOrdersByUserID(authz.WithSystem(context.Background()), entity.OrderQuery{UserID: 1, Limit: 10000})
+
+		// Проверка
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if page.Orders == nil || len(page.Orders) != 0 {
+			t.Errorf("expected empty orders, got %v", page.Orders)
 		}
 	})
 
 	t.Run("RepositoryError", func(t *testing.T) {
 		// Подготовка
-		mockRepo.EXPECT().GetOrdersByUserID(int64(1)).Return(nil, errors.New("database error"))
+		mockRepo.EXPECT().GetOrdersByUserID(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
 
 		// Выполнение
-		orders, err := service.GetOrdersByUserID(1)
+		page, err := service.GetOrdersByUserID(authz.WithSystem(context.Background()), entity.OrderQuery{UserID: 1})
 
 		// Проверка
 		if err == nil || err.Error() != "database error" {
 			t.Errorf("expected error 'database error', got %v", err)
 		}
-		if orders != nil {
-			t.Errorf("expected nil orders, got %v", orders)
+		if page != nil {
+			t.Errorf("expected nil page, got %v", page)
+		}
+	})
+
+	t.Run("UserRequired", func(t *testing.T) {
+		// Выполнение
+		page, err := service.GetOrdersByUserID(authz.WithSystem(context.Background()), entity.OrderQuery{IncludeDeleted: true})
+
+		// Проверка
+		if err == nil || page != nil {
+			t.Errorf("expected error without user, got %v, %v", page, err)
+		}
+	})
+}
+
+func TestOrderService_ListOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockProductClient := GrpcMocks.NewMockProductServiceClientInterface(ctrl)
+	mockPaymentClient := GrpcMocks.NewMockPaymentServiceClientInterface(ctrl)
+	service := NewOrderService(mockRepo, mockProductClient, mockPaymentClient)
+
+	t.Run("AllUsers", func(t *testing.T) {
+		// Подготовка
+		expectedOrders := []entity.Order{{ID: 2, UserID: 7}, {ID: 1, UserID: 3}}
+		expectedQuery := entity.OrderQuery{Sort: entity.SortDesc, Limit: DefaultOrderPageSize + 1, IncludeDeleted: true}
+		mockRepo.EXPECT().GetOrdersByUserID(gomock.Any(), expectedQuery).Return(expectedOrders, nil)
+
+		// Выполнение
+		page, err := service.ListOrders(authz.WithSystem(context.Background()), entity.OrderQuery{IncludeDeleted: true})
+
+		// Проверка
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(page.Orders) != 2 || page.NextCursor != "" {
+			t.Errorf("expected orders of all users without next cursor, got %+v", page)
+		}
+	})
+}
+
+func TestOrderService_Authorization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockProductClient := GrpcMocks.NewMockProductServiceClientInterface(ctrl)
+	mockPaymentClient := GrpcMocks.NewMockPaymentServiceClientInterface(ctrl)
+	service := NewOrderService(mockRepo, mockProductClient, mockPaymentClient)
+
+	order := &entity.Order{ID: 5, UserID: 7, Status: entity.OrderStatusPaid, Version: 2}
+	asUser := func(userID int64, role string) context.Context {
+		return authz.WithPrincipal(context.Background(), authz.Principal{UserID: userID, Role: role})
+	}
+
+	t.Run("OwnerViewsOrder", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(order, nil)
+
+		// Выполнение
+		got, err := service.GetOrderByID(asUser(7, ""), 5)
+
+		// Проверка
+		if err != nil || got.ID != 5 {
+			t.Errorf("expected owner to see the order, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("StrangerGetsNotFound", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(order, nil)
+
+		// Выполнение
+		got, err := service.GetOrderHistory(asUser(8, ""), 5)
+
+		// Проверка
+		if got != nil || !errors.Is(err, domainerr.NotFound) || !errors.Is(err, authz.ErrForbidden) {
+			t.Errorf("expected hidden denial, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("SupportCannotUpdateStatus", func(t *testing.T) {
+		// Подготовка: отказ не повторяется как конфликт версий
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(order, nil).Times(1)
+
+		// Выполнение
+		err := service.UpdateOrderStatus(asUser(100, entity.RoleSupport), 5, entity.OrderStatusShipped, entity.StaffActor(entity.RoleSupport, 100), "проверка", 0)
+
+		// Проверка
+		var denied *authz.DeniedError
+		if !errors.As(err, &denied) || denied.Hidden {
+			t.Errorf("expected visible denial, got %v", err)
+		}
+	})
+
+	t.Run("CancelForAnotherUser", func(t *testing.T) {
+		// Выполнение: до репозитория запрос не доходит
+		err := service.CancelOrder(asUser(8, ""), 7, 5, 0)
+
+		// Проверка
+		if !errors.Is(err, authz.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+
+	t.Run("UserCannotListAll", func(t *testing.T) {
+		// Выполнение
+		page, err := service.ListOrders(asUser(7, ""), entity.OrderQuery{})
+
+		// Проверка
+		if page != nil || !errors.Is(err, authz.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v, %v", page, err)
+		}
+	})
+
+	t.Run("NoPrincipalDenied", func(t *testing.T) {
+		// Подготовка
+		mockRepo.EXPECT().GetOrderByID(gomock.Any(), int64(5)).Return(order, nil)
+
+		// Выполнение: вызов без принципала не должен проходить проверку
+		err := service.UpdateOrderStatus(context.Background(), 5, entity.OrderStatusShipped, "kafka:shipping_events", "", 0)
+
+		// Проверка
+		if !errors.Is(err, authz.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+
+	t.Run("UserCannotRecoverSagas", func(t *testing.T) {
+		// Выполнение
+		_, err := service.RecoverSagas(asUser(7, ""), time.Minute)
+
+		// Проверка
+		if !errors.Is(err, authz.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
 		}
 	})
 }
@@ -356,10 +1211,10 @@ func TestOrderService_CancelOrder(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		// Подготовка
-		mockRepo.EXPECT().CancelOrder(int64(1), int64(1)).Return(nil)
+		mockRepo.EXPECT().CancelOrder(gomock.Any(), int64(1), int64(1), int64(3)).Return(nil)
 
 		// Выполнение
-		err := service.CancelOrder(1, 1)
+		err := service.CancelOrder(authz.WithSystem(context.Background()), 1, 1, 3)
 
 		// Проверка
 		if err != nil {
@@ -369,10 +1224,10 @@ func TestOrderService_CancelOrder(t *testing.T) {
 
 	t.Run("RepositoryError", func(t *testing.T) {
 		// Подготовка
-		mockRepo.EXPECT().CancelOrder(int64(1), int64(1)).Return(errors.New("database error"))
+		mockRepo.EXPECT().CancelOrder(gomock.Any(), int64(1), int64(1), int64(0)).Return(errors.New("database error"))
 
 		// Выполнение
-		err := service.CancelOrder(1, 1)
+		err := service.CancelOrder(authz.WithSystem(context.Background()), 1, 1, 0)
 
 		// Проверка
 		if err == nil || err.Error() != "database error" {
@@ -392,10 +1247,10 @@ func TestOrderService_DeleteOrder(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		// Подготовка
-		mockRepo.EXPECT().Delete(int64(1)).Return(nil)
+		mockRepo.EXPECT().Delete(gomock.Any(), int64(1)).Return(nil)
 
 		// Выполнение
-		err := service.DeleteOrder(1)
+		err := service.DeleteOrder(authz.WithSystem(context.Background()), 1)
 
 		// Проверка
 		if err != nil {
@@ -405,10 +1260,10 @@ func TestOrderService_DeleteOrder(t *testing.T) {
 
 	t.Run("RepositoryError", func(t *testing.T) {
 		// Подготовка
-		mockRepo.EXPECT().Delete(int64(1)).Return(errors.New("database error"))
+		mockRepo.EXPECT().Delete(gomock.Any(), int64(1)).Return(errors.New("database error"))
 
 		// Выполнение
-		err := service.DeleteOrder(1)
+		err := service.DeleteOrder(authz.WithSystem(context.Background()), 1)
 
 		// Проверка
 		if err == nil || err.Error() != "database error" {
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"order_service/internal/authz"
+	"order_service/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultOutboxBatchSize = 100
+
+// OutboxConfig — настройки публикации событий из outbox
+type OutboxConfig struct {
+	Interval  time.Duration // как часто проверять outbox
+	BatchSize int           // сколько событий публиковать за один проход
+}
+
+// OutboxRelay публикует события из outbox и отмечает их отправленными.
+// Пачка читается и отмечается в одной транзакции, поэтому другие экземпляры
+// сервиса её не захватывают. Доставка «хотя бы один раз»: если процесс упадёт
+// между публикацией и отметкой, событие будет отправлено повторно.
+type OutboxRelay struct {
+	repo      repository.OrderRepository
+	publisher EventPublisher
+	cfg       OutboxConfig
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewOutboxRelay(repo repository.OrderRepository, publisher EventPublisher, cfg OutboxConfig) *OutboxRelay {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultOutboxBatchSize
+	}
+	return &OutboxRelay{
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start запускает цикл публикации и блокируется до вызова Stop
+func (r *OutboxRelay) Start() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			// Полная пачка означает, что в outbox могут остаться события — продолжаем сразу,
+			// пока не попросили остановиться
+			for !r.stopped() && r.RunOnce(authz.WithSystem(context.Background())) == r.cfg.BatchSize {
+			}
+		}
+	}
+}
+
+// Stop останавливает цикл и дожидается завершения текущей публикации
+func (r *OutboxRelay) Stop() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *OutboxRelay) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunOnce публикует одну пачку событий и возвращает количество отправленных.
+// События публикуются по порядку; на первой ошибке пачка прерывается,
+// чтобы события одного заказа не обгоняли друг друга.
+func (r *OutboxRelay) RunOnce(ctx context.Context) int {
+	var sent []int64
+	err := r.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		sent = nil
+		events, err := r.repo.GetUnsentEvents(ctx, r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("read outbox: %w", err)
+		}
+
+		for _, event := range events {
+			if err := r.publisher.Publish(ctx, event); err != nil {
+				logrus.Errorf("Не удалось опубликовать событие %d (%s): %v", event.ID, event.Type, err)
+				break
+			}
+			sent = append(sent, event.ID)
+		}
+
+		if len(sent) == 0 {
+			return nil
+		}
+		if err := r.repo.MarkEventsSent(ctx, sent); err != nil {
+			// События уже в Kafka и будут отправлены повторно
+			return fmt.Errorf("mark events sent: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logrus.Error("Ошибка публикации событий из outbox: ", err)
+		return 0
+	}
+	return len(sent)
+}
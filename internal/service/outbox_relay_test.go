@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"order_service/internal/entity"
+	RepoMocks "order_service/internal/repository/mocks"
+	ServiceMocks "order_service/internal/service/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestOutboxRelay_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockPublisher := ServiceMocks.NewMockEventPublisher(ctrl)
+	relay := NewOutboxRelay(mockRepo, mockPublisher, OutboxConfig{Interval: time.Second, BatchSize: 10})
+
+	events := []entity.OutboxEvent{
+		{ID: 1, OrderID: 7, Type: entity.OrderEventCreated, Payload: []byte(`{}`)},
+		{ID: 2, OrderID: 7, Type: entity.OrderEventCanceled, Payload: []byte(`{}`)},
+	}
+
+	t.Run("PublishesAndMarksSent", func(t *testing.T) {
+		// Подготовка
+		expectTx(mockRepo)
+		mockRepo.EXPECT().GetUnsentEvents(gomock.Any(), 10).Return(events, nil)
+		gomock.InOrder(
+			mockPublisher.EXPECT().Publish(gomock.Any(), events[0]).Return(nil),
+			mockPublisher.EXPECT().Publish(gomock.Any(), events[1]).Return(nil),
+		)
+		mockRepo.EXPECT().MarkEventsSent(gomock.Any(), []int64{1, 2}).Return(nil)
+
+		// Выполнение
+		sent := relay.RunOnce(t.Context())
+
+		// Проверка
+		if sent != 2 {
+			t.Errorf("expected 2 sent events, got %d", sent)
+		}
+	})
+
+	t.Run("StopsOnPublishError", func(t *testing.T) {
+		// Подготовка
+		expectTx(mockRepo)
+		mockRepo.EXPECT().GetUnsentEvents(gomock.Any(), 10).Return(events, nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), events[0]).Return(nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), events[1]).Return(errors.New("broker unavailable"))
+		// Неопубликованное событие остаётся в outbox до следующего прохода
+		mockRepo.EXPECT().MarkEventsSent(gomock.Any(), []int64{1}).Return(nil)
+
+		// Выполнение
+		sent := relay.RunOnce(t.Context())
+
+		// Проверка
+		if sent != 1 {
+			t.Errorf("expected 1 sent event, got %d", sent)
+		}
+	})
+
+	t.Run("NothingPublished", func(t *testing.T) {
+		// Подготовка
+		expectTx(mockRepo)
+		mockRepo.EXPECT().GetUnsentEvents(gomock.Any(), 10).Return(events[:1], nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), events[0]).Return(errors.New("broker unavailable"))
+
+		// Выполнение
+		sent := relay.RunOnce(t.Context())
+
+		// Проверка
+		if sent != 0 {
+			t.Errorf("expected 0 sent events, got %d", sent)
+		}
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		// Подготовка
+		expectTx(mockRepo)
+		mockRepo.EXPECT().GetUnsentEvents(gomock.Any(), 10).Return(nil, errors.New("database error"))
+
+		// Выполнение
+		sent := relay.RunOnce(t.Context())
+
+		// Проверка
+		if sent != 0 {
+			t.Errorf("expected 0 sent events, got %d", sent)
+		}
+	})
+
+	t.Run("MarkSentError", func(t *testing.T) {
+		// Подготовка: транзакция откатывается, события будут опубликованы повторно
+		expectTx(mockRepo)
+		mockRepo.EXPECT().GetUnsentEvents(gomock.Any(), 10).Return(events[:1], nil)
+		mockPublisher.EXPECT().Publish(gomock.Any(), events[0]).Return(nil)
+		mockRepo.EXPECT().MarkEventsSent(gomock.Any(), []int64{1}).Return(errors.New("database error"))
+
+		// Выполнение
+		sent := relay.RunOnce(t.Context())
+
+		// Проверка
+		if sent != 0 {
+			t.Errorf("expected 0 sent events, got %d", sent)
+		}
+	})
+}
+
+func TestOutboxRelay_StopDuringBacklog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := RepoMocks.NewMockOrderRepository(ctrl)
+	mockPublisher := ServiceMocks.NewMockEventPublisher(ctrl)
+	relay := NewOutboxRelay(mockRepo, mockPublisher, OutboxConfig{Interval: time.Millisecond, BatchSize: 1})
+
+	// Подготовка: outbox никогда не пустеет, каждый проход публикует полную пачку
+	event := entity.OutboxEvent{ID: 1, OrderID: 7, Type: entity.OrderEventCreated, Payload: []byte(`{}`)}
+	mockRepo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	}).AnyTimes()
+	mockRepo.EXPECT().GetUnsentEvents(gomock.Any(), 1).Return([]entity.OutboxEvent{event}, nil).AnyTimes()
+	mockRepo.EXPECT().MarkEventsSent(gomock.Any(), []int64{1}).Return(nil).AnyTimes()
+	published := make(chan struct{}, 1)
+	mockPublisher.EXPECT().Publish(gomock.Any(), event).DoAndReturn(func(context.Context, entity.OutboxEvent) error {
+		select {
+		case published <- struct{}{}:
+		default:
+		}
+		return nil
+	}).AnyTimes()
+
+	// Выполнение
+	go relay.Start()
+	<-published
+	stopped := make(chan struct{})
+	go func() {
+		relay.Stop()
+		close(stopped)
+	}()
+
+	// Проверка
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected Stop to return while the outbox still has events")
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"order_service/internal/entity"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Producer публикует события заказов в топик Kafka
+type Producer struct {
+	producer *kafka.Producer
+	topic    string
+}
+
+func NewProducer(address []string, topic string) (*Producer, error) {
+	cfg := &kafka.ConfigMap{
+		"bootstrap.servers":  strings.Join(address, ","),
+		"acks":               "all",
+		"enable.idempotence": true,
+	}
+	p, err := kafka.NewProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{producer: p, topic: topic}, nil
+}
+
+// Publish отправляет событие и ждёт подтверждения доставки.
+// Ключ сообщения — ID заказа, поэтому события одного заказа попадают в одну партицию по порядку.
+// ID записи outbox передаётся в заголовке event_id: при повторной доставке по нему можно отбросить дубликат.
+func (p *Producer) Publish(ctx context.Context, event entity.OutboxEvent) error {
+	deliveryChan := make(chan kafka.Event, 1)
+	err := p.producer.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &p.topic, Partition: kafka.PartitionAny},
+		Key:            []byte(strconv.FormatInt(event.OrderID, 10)),
+		Value:          event.Payload,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(strconv.FormatInt(event.ID, 10))},
+			{Key: "event_type", Value: []byte(event.Type)},
+		},
+	}, deliveryChan)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case e := <-deliveryChan:
+		if m, ok := e.(*kafka.Message); ok {
+			return m.TopicPartition.Error
+		}
+		if kafkaErr, ok := e.(kafka.Error); ok {
+			return kafkaErr
+		}
+		return nil
+	}
+}
+
+func (p *Producer) Close() {
+	p.producer.Flush(5000)
+	p.producer.Close()
+}
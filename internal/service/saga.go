@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"order_service/internal/entity"
+	"order_service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sagaStep — шаг саги и его компенсирующее действие
+type sagaStep struct {
+	name       entity.SagaStep
+	action     func(ctx context.Context) error
+	compensate func(ctx context.Context) error // nil — компенсировать нечего
+	savesState bool                            // действие само сохраняет шаг в своей транзакции
+}
+
+// saga выполняет шаги по порядку и сохраняет состояние после каждого из них.
+// При ошибке шага, в том числе при ошибке сохранения его состояния, компенсации
+// уже выполненных шагов запускаются в обратном порядке.
+type saga struct {
+	repo  repository.OrderRepository
+	state *entity.OrderSaga
+	steps []sagaStep
+}
+
+func (s *saga) run(ctx context.Context) error {
+	for i, step := range s.steps {
+		if err := step.action(ctx); err != nil {
+			return s.fail(ctx, i, err)
+		}
+		if step.savesState {
+			continue
+		}
+
+		// Без сохранённого шага восстановление не узнает о выполненном действии,
+		// поэтому шаг откатывается, а не продолжается
+		s.state.Step = step.name
+		if err := s.repo.UpdateSaga(ctx, s.state); err != nil {
+			return s.fail(ctx, i, fmt.Errorf("failed to save saga step %s: %w", step.name, err))
+		}
+	}
+
+	s.state.Status = entity.SagaStatusCompleted
+	if err := s.repo.UpdateSaga(ctx, s.state); err != nil {
+		logrus.Errorf("Сага %d: не удалось сохранить завершение: %v", s.state.ID, err)
+	}
+	return nil
+}
+
+// fail запоминает ошибку шага failed и откатывает выполненные шаги
+func (s *saga) fail(ctx context.Context, failed int, err error) error {
+	s.state.Error = err.Error()
+	// Компенсация доводится до конца, даже если клиент уже отключился
+	if compErr := s.compensate(context.WithoutCancel(ctx), failed); compErr != nil {
+		logrus.Errorf("Сага %d: компенсация не завершена: %v", s.state.ID, compErr)
+	}
+	return err
+}
+
+// compensate откатывает шаги до failed (включительно, он мог выполниться частично)
+func (s *saga) compensate(ctx context.Context, failed int) error {
+	s.state.Status = entity.SagaStatusCompensating
+	if err := s.repo.UpdateSaga(ctx, s.state); err != nil {
+		return err
+	}
+
+	for i := failed; i >= 0; i-- {
+		step := s.steps[i]
+		if step.compensate == nil {
+			continue
+		}
+		if err := step.compensate(ctx); err != nil {
+			return fmt.Errorf("compensate %s: %w", step.name, err)
+		}
+	}
+
+	s.state.Status = entity.SagaStatusCompensated
+	return s.repo.UpdateSaga(ctx, s.state)
+}
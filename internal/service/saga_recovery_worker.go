@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"order_service/internal/authz"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SagaRecoveryWorker периодически доводит до конца или откатывает саги,
+// прерванные падением процесса
+type SagaRecoveryWorker struct {
+	orderService OrderServiceInterface
+	interval     time.Duration
+	staleAfter   time.Duration
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+func NewSagaRecoveryWorker(orderService OrderServiceInterface, interval, staleAfter time.Duration) *SagaRecoveryWorker {
+	return &SagaRecoveryWorker{
+		orderService: orderService,
+		interval:     interval,
+		staleAfter:   staleAfter,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start сразу восстанавливает саги, оставшиеся с прошлого запуска,
+// затем повторяет проверку по таймеру до вызова Stop
+func (w *SagaRecoveryWorker) Start() {
+	defer close(w.done)
+
+	w.RunOnce(authz.WithSystem(context.Background()))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.RunOnce(authz.WithSystem(context.Background()))
+		}
+	}
+}
+
+// Stop останавливает цикл и дожидается завершения текущей проверки
+func (w *SagaRecoveryWorker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// RunOnce выполняет одну проверку и возвращает количество восстановленных саг
+func (w *SagaRecoveryWorker) RunOnce(ctx context.Context) int {
+	recovered, err := w.orderService.RecoverSagas(ctx, w.staleAfter)
+	if err != nil {
+		logrus.Error("Ошибка при восстановлении саг: ", err)
+		return 0
+	}
+	if recovered > 0 {
+		logrus.Infof("Восстановлено саг: %d", recovered)
+	}
+	return recovered
+}
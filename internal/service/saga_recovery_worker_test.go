@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ServiceMocks "order_service/internal/service/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestSagaRecoveryWorker_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderService := ServiceMocks.NewMockOrderServiceInterface(ctrl)
+	worker := NewSagaRecoveryWorker(mockOrderService, time.Minute, 2*time.Minute)
+
+	t.Run("RecoversSagas", func(t *testing.T) {
+		// Подготовка
+		mockOrderService.EXPECT().RecoverSagas(gomock.Any(), 2*time.Minute).Return(3, nil)
+
+		// Выполнение
+		recovered := worker.RunOnce(context.Background())
+
+		// Проверка
+		if recovered != 3 {
+			t.Errorf("expected 3 recovered sagas, got %d", recovered)
+		}
+	})
+
+	t.Run("ServiceError", func(t *testing.T) {
+		// Подготовка
+		mockOrderService.EXPECT().RecoverSagas(gomock.Any(), 2*time.Minute).Return(0, errors.New("database error"))
+
+		// Выполнение
+		recovered := worker.RunOnce(context.Background())
+
+		// Проверка
+		if recovered != 0 {
+			t.Errorf("expected 0 recovered sagas, got %d", recovered)
+		}
+	})
+}
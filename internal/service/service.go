@@ -1,14 +1,29 @@
 package service
 
 import (
+	"context"
 	"order_service/internal/entity"
+	"time"
 )
 
 type OrderServiceInterface interface {
-	CreateOrder(UserID int64, Items []entity.OrderItem, TotalPrice float64) (*entity.PaymentResponse, error)
-	GetOrderByID(orderID int64) (*entity.Order, error)
-	GetOrdersByUserID(userID int64) ([]entity.Order, error)
-	UpdateOrderStatus(orderID int64, status string) error
-	DeleteOrder(orderID int64) error
-	CancelOrder(userID int64, orderID int64) error
+	CreateOrder(ctx context.Context, UserID int64, Items []entity.OrderItem, TotalPrice entity.Money) (*entity.PaymentResponse, error)
+	CreateOrderIdempotent(ctx context.Context, UserID int64, Key string, Items []entity.OrderItem, TotalPrice entity.Money) (*entity.PaymentResponse, error)
+	GetOrderByID(ctx context.Context, orderID int64) (*entity.Order, error)
+	GetOrderByIDIncludingDeleted(ctx context.Context, orderID int64) (*entity.Order, error)
+	GetOrdersByUserID(ctx context.Context, query entity.OrderQuery) (*entity.OrderPage, error)
+	ListOrders(ctx context.Context, query entity.OrderQuery) (*entity.OrderPage, error)
+	UpdateOrderStatus(ctx context.Context, orderID int64, status entity.OrderStatus, actor, reason string, expectedVersion int64) error
+	GetOrderHistory(ctx context.Context, orderID int64) ([]entity.StatusChange, error)
+	GetOrderHistoryIncludingDeleted(ctx context.Context, orderID int64) ([]entity.StatusChange, error)
+	DeleteOrder(ctx context.Context, orderID int64) error
+	RestoreOrder(ctx context.Context, orderID int64) error
+	CancelOrder(ctx context.Context, userID int64, orderID int64, expectedVersion int64) error
+	RecoverSagas(ctx context.Context, staleAfter time.Duration) (int, error)
+}
+
+// EventPublisher доставляет события outbox во внешний брокер.
+// Publish возвращает nil только после подтверждения записи брокером.
+type EventPublisher interface {
+	Publish(ctx context.Context, event entity.OutboxEvent) error
 }
@@ -6,9 +6,21 @@ export DB_HOST=$(hostname -I | awk '{print $1}')
 export DB_PORT=5432
 export DB_TYPE='postgres'
 export DB_SSLMODE=disable
+export SQLITE_PATH='order_service.db'
+export MONGO_URI="mongodb://${DB_HOST}:27017/?replicaSet=rs0"
+export MONGO_DB='order_service'
 
 export DB_ORDER_SERVICE='order_service'
 export USER_ORDER_SERVICE='order_service'
 export PASSWORD_ORDER_SERVICE='aeva0lah0eejaiphaiPhie'
 export CGO_ENABLED=1
 export JWT_SECRET_KEY='kahxein2Theey2Jae8Doh1'
+export RESERVATION_TTL='5m'
+export RESERVATION_SWEEP_INTERVAL='1m'
+export INVALIDATE_PAYMENT_LINKS='false'
+export SAGA_RECOVERY_INTERVAL='1m'
+export SAGA_RECOVERY_AFTER='2m'
+export ORDER_EVENTS_TOPIC='order_events'
+export OUTBOX_RELAY_INTERVAL='1s'
+export IDEMPOTENCY_KEY_TTL='24h'
+export SCHEMA_CHECK='on'